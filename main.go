@@ -7,7 +7,23 @@ import (
 
 func main() {
 
-	s := server.NewServer()
+	s, err := server.NewServer()
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	go s.WatchConfig(nil)
+
+	if s.Config.NamespaceController {
+
+		client, err := server.NewInClusterClient()
+		if err != nil {
+			glog.Fatal(err)
+		}
+
+		go s.RunNamespaceController(client, nil)
+
+	}
 
 	if s.IsTLSEnable() {
 
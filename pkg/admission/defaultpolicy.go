@@ -0,0 +1,94 @@
+package admission
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DefaultNetworkPolicyAnnotation is set on the namespaces where the default
+// NetworkPolicy was created, they are never provisioned again so a team can
+// delete or replace the policy.
+const DefaultNetworkPolicyAnnotation = "karepol.io/default-network-policy"
+
+// DefaultNetworkPolicyLabel set to "true" opts a namespace created before
+// the --namespace-controller-since time in to its default NetworkPolicy.
+const DefaultNetworkPolicyLabel = "karepol.io/default-network-policy-opt-in"
+
+// defaultNetworkPolicy names the warnings returned for new namespaces
+const defaultNetworkPolicy RuleName = "DefaultNetworkPolicy"
+
+// NamespaceValidator describes how new namespaces are handled
+type NamespaceValidator struct {
+	// WarnOnCreate returns a warning to the client creating a namespace
+	// that starts without a NetworkPolicy
+	WarnOnCreate bool `json:"warnOnCreate,omitempty"`
+	// DefaultNetworkPolicy is the template of the NetworkPolicy created in
+	// new namespaces by the namespace controller, e.g. a default-deny policy
+	DefaultNetworkPolicy *networkingv1.NetworkPolicy `json:"defaultNetworkPolicy,omitempty"`
+}
+
+// compile checks the default NetworkPolicy template
+func (v *NamespaceValidator) compile() error {
+
+	if v.DefaultNetworkPolicy == nil {
+		return nil
+	}
+
+	if v.DefaultNetworkPolicy.Name == "" {
+		return fmt.Errorf("defaultNetworkPolicy: metadata.name is required")
+	}
+
+	if v.DefaultNetworkPolicy.Namespace != "" {
+		return fmt.Errorf("defaultNetworkPolicy: metadata.namespace can't be set")
+	}
+
+	return nil
+}
+
+// ValidateNamespace returns the warnings of a namespace being created with
+// the namespace rules of its profile, the profile is selected with the
+// namespace name and labels. controller is true when the namespace
+// controller runs and creates the default NetworkPolicy.
+func (v *NetworkAdmissionValidator) ValidateNamespace(ns *corev1.Namespace, controller bool) ViolationList {
+
+	n := &v.validators(ns.Name, ns.Labels).NamespaceValidator
+
+	if !n.WarnOnCreate {
+		return nil
+	}
+
+	msg := fmt.Sprintf("namespace %s has no NetworkPolicy, it allows all traffic until one is created", ns.Name)
+	if _, ok := ns.Annotations[DefaultNetworkPolicyAnnotation]; controller && !ok && n.DefaultNetworkPolicy != nil {
+		msg = fmt.Sprintf("namespace %s has no NetworkPolicy, the default NetworkPolicy %s will be created", ns.Name, n.DefaultNetworkPolicy.Name)
+	}
+
+	return ViolationList{{
+		Field:       field.NewPath("metadata", "name").String(),
+		Rule:        defaultNetworkPolicy,
+		Message:     msg,
+		Enforcement: EnforcementWarn,
+	}}
+}
+
+// DefaultNetworkPolicy returns the default NetworkPolicy of the profile of
+// namespace ns or nil when it has none or the namespace was already
+// provisioned.
+func (v *NetworkAdmissionValidator) DefaultNetworkPolicy(ns *corev1.Namespace) *networkingv1.NetworkPolicy {
+
+	if _, ok := ns.Annotations[DefaultNetworkPolicyAnnotation]; ok {
+		return nil
+	}
+
+	t := v.validators(ns.Name, ns.Labels).NamespaceValidator.DefaultNetworkPolicy
+	if t == nil {
+		return nil
+	}
+
+	p := t.DeepCopy()
+	p.Namespace = ns.Name
+
+	return p
+}
@@ -0,0 +1,116 @@
+package admission
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const namespaceConfig = `
+namespaceValidator:
+  warnOnCreate: true
+  defaultNetworkPolicy:
+    metadata:
+      name: default-deny
+      labels:
+        karepol.io/managed: "true"
+    spec:
+      podSelector: {}
+      policyTypes:
+      - Ingress
+      - Egress
+profiles:
+- name: system
+  namespaces:
+  - kube-*
+- name: quiet
+  namespaceSelector:
+    matchLabels:
+      env: sandbox
+  namespaceValidator:
+    defaultNetworkPolicy:
+      metadata:
+        name: deny-ingress
+      spec:
+        podSelector: {}
+        policyTypes:
+        - Ingress
+`
+
+func TestValidateNamespace(t *testing.T) {
+
+	tests := []struct {
+		namespace   corev1.Namespace
+		controller  bool
+		warning     string
+		policy      string
+		policyTypes int
+	}{
+		{corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}, true, "default NetworkPolicy default-deny will be created", "default-deny", 2},
+		{corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}, false, "has no NetworkPolicy, it allows all traffic", "default-deny", 2},
+		{corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Annotations: map[string]string{DefaultNetworkPolicyAnnotation: ""}}}, true, "has no NetworkPolicy, it allows all traffic", "", 0},
+		{corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-public"}}, true, "", "", 0},
+		{corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sandbox", Labels: map[string]string{"env": "sandbox"}}}, true, "", "deny-ingress", 1},
+	}
+
+	v, err := parseAdmissionValidator([]byte(namespaceConfig))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		l := v.ValidateNamespace(&i.namespace, i.controller)
+
+		if i.warning == "" && len(l) > 0 || i.warning != "" && (len(l) != 1 || !strings.Contains(l[0].Message, i.warning)) {
+			t.Errorf("%s: warnings were %v and expected is %q", i.namespace.Name, l, i.warning)
+		}
+
+		if len(l) > 0 && l[0].Enforcement != EnforcementWarn {
+			t.Errorf("%s: enforcement was %s and expected is %s", i.namespace.Name, l[0].Enforcement, EnforcementWarn)
+		}
+
+		p := v.DefaultNetworkPolicy(&i.namespace)
+
+		if i.policy == "" {
+			if p != nil {
+				t.Errorf("%s: policy was %v and expected is none", i.namespace.Name, p)
+			}
+			continue
+		}
+
+		if p == nil || p.Name != i.policy || p.Namespace != i.namespace.Name || len(p.Spec.PolicyTypes) != i.policyTypes {
+			t.Errorf("%s: policy was %v and expected is %s with %d policy types", i.namespace.Name, p, i.policy, i.policyTypes)
+		}
+
+	}
+
+	// the template is copied for every namespace
+	a := v.DefaultNetworkPolicy(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+	a.Labels["changed"] = "true"
+	if b := v.DefaultNetworkPolicy(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "b"}}); len(b.Labels) != 1 {
+		t.Errorf("template labels were %v and expected is 1 label", b.Labels)
+	}
+
+}
+
+func TestNamespaceValidatorErrors(t *testing.T) {
+
+	tests := []string{
+		"namespaceValidator:\n  defaultNetworkPolicy:\n    spec:\n      podSelector: {}\n",
+		"namespaceValidator:\n  defaultNetworkPolicy:\n    metadata:\n      name: a\n      namespace: b\n",
+		"namespaceValidator:\n  defaultNetworkPolicy:\n    metadata:\n      name: a\n    spec:\n      podSelectr: {}\n",
+		"namespaceValidator:\n  warnOnCreate: yes please\n",
+	}
+
+	for _, i := range tests {
+
+		if v, err := parseAdmissionValidator([]byte(i)); err == nil {
+			t.Errorf("%q: expected an error, got validator %+v", i, v)
+		}
+
+	}
+
+}
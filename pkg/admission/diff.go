@@ -0,0 +1,268 @@
+package admission
+
+import (
+	"fmt"
+	"net"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// changeType is the kind of a change between the old and the new spec of a
+// NetworkPolicy
+type changeType string
+
+const (
+	podSelectorChanged changeType = "PodSelectorChanged"
+	policyTypeAdded    changeType = "PolicyTypeAdded"
+	policyTypeRemoved  changeType = "PolicyTypeRemoved"
+	ruleAdded          changeType = "RuleAdded"
+	ruleRemoved        changeType = "RuleRemoved"
+	peerAdded          changeType = "PeerAdded"
+	peerRemoved        changeType = "PeerRemoved"
+	peerChanged        changeType = "PeerChanged"
+	portAdded          changeType = "PortAdded"
+	portRemoved        changeType = "PortRemoved"
+	cidrWidened        changeType = "CIDRWidened"
+	cidrNarrowed       changeType = "CIDRNarrowed"
+	exceptAdded        changeType = "ExceptAdded"
+	exceptRemoved      changeType = "ExceptRemoved"
+)
+
+// policyChange is a change between the old and the new spec of a
+// NetworkPolicy. Rules and peers are compared by position, ports, policy
+// types and except entries as sets.
+type policyChange struct {
+	Type    changeType
+	FldPath *field.Path
+	Old     string
+	New     string
+	// Widening is true when the change may allow traffic the old spec
+	// denied
+	Widening bool
+}
+
+func (c *policyChange) String() string {
+
+	switch {
+
+	case c.Old != "" && c.New != "":
+
+		return fmt.Sprintf("%s %s -> %s", c.Type, c.Old, c.New)
+
+	case c.Old != "":
+
+		return fmt.Sprintf("%s %s", c.Type, c.Old)
+
+	}
+
+	return fmt.Sprintf("%s %s", c.Type, c.New)
+}
+
+// diffNetworkPolicySpec returns the changes from spec old to spec p
+func diffNetworkPolicySpec(old, p *networkingv1.NetworkPolicySpec, fldPath *field.Path) []policyChange {
+
+	var changes []policyChange
+
+	if o, n := metav1.FormatLabelSelector(&old.PodSelector), metav1.FormatLabelSelector(&p.PodSelector); o != n {
+		changes = append(changes, policyChange{podSelectorChanged, fldPath.Child("podSelector"), o, n, true})
+	}
+
+	oldTypes, newTypes := policyTypes(old), policyTypes(p)
+	for _, t := range newTypes {
+		if !contains(oldTypes, t) {
+			changes = append(changes, policyChange{policyTypeAdded, fldPath.Child("policyTypes"), "", t, false})
+		}
+	}
+	for _, t := range oldTypes {
+		if !contains(newTypes, t) {
+			changes = append(changes, policyChange{policyTypeRemoved, fldPath.Child("policyTypes"), t, "", true})
+		}
+	}
+
+	ingress := fldPath.Child("ingress")
+	for i := 0; i < len(old.Ingress) || i < len(p.Ingress); i++ {
+
+		switch {
+
+		case i >= len(old.Ingress):
+
+			changes = append(changes, policyChange{ruleAdded, ingress.Index(i), "", "", true})
+
+		case i >= len(p.Ingress):
+
+			changes = append(changes, policyChange{ruleRemoved, ingress.Index(i), "", "", false})
+
+		default:
+
+			changes = append(changes, diffPeers(old.Ingress[i].From, p.Ingress[i].From, ingress.Index(i).Child("from"))...)
+			changes = append(changes, diffPorts(old.Ingress[i].Ports, p.Ingress[i].Ports, ingress.Index(i).Child("ports"))...)
+
+		}
+	}
+
+	egress := fldPath.Child("egress")
+	for i := 0; i < len(old.Egress) || i < len(p.Egress); i++ {
+
+		switch {
+
+		case i >= len(old.Egress):
+
+			changes = append(changes, policyChange{ruleAdded, egress.Index(i), "", "", true})
+
+		case i >= len(p.Egress):
+
+			changes = append(changes, policyChange{ruleRemoved, egress.Index(i), "", "", false})
+
+		default:
+
+			changes = append(changes, diffPeers(old.Egress[i].To, p.Egress[i].To, egress.Index(i).Child("to"))...)
+			changes = append(changes, diffPorts(old.Egress[i].Ports, p.Egress[i].Ports, egress.Index(i).Child("ports"))...)
+
+		}
+	}
+
+	return changes
+}
+
+// policyTypes returns the policy types of spec p, a spec without policy
+// types applies to Ingress, and to Egress when it has egress rules.
+func policyTypes(p *networkingv1.NetworkPolicySpec) []string {
+
+	var types []string
+	for _, t := range p.PolicyTypes {
+		types = append(types, string(t))
+	}
+
+	if len(types) == 0 {
+		types = append(types, string(networkingv1.PolicyTypeIngress))
+		if len(p.Egress) > 0 {
+			types = append(types, string(networkingv1.PolicyTypeEgress))
+		}
+	}
+
+	return types
+}
+
+// diffPeers compares the peers of a rule, an empty list allows every peer.
+func diffPeers(old, p []networkingv1.NetworkPolicyPeer, fldPath *field.Path) []policyChange {
+
+	var changes []policyChange
+
+	for i := 0; i < len(old) || i < len(p); i++ {
+
+		switch {
+
+		case i >= len(old):
+
+			changes = append(changes, policyChange{peerAdded, fldPath.Index(i), "", "", len(old) > 0})
+
+		case i >= len(p):
+
+			changes = append(changes, policyChange{peerRemoved, fldPath.Index(i), "", "", len(p) == 0})
+
+		case old[i].IPBlock != nil && p[i].IPBlock != nil:
+
+			changes = append(changes, diffIPBlock(old[i].IPBlock, p[i].IPBlock, fldPath.Index(i).Child("ipBlock"))...)
+
+		case !equality.Semantic.DeepEqual(old[i], p[i]):
+
+			changes = append(changes, policyChange{peerChanged, fldPath.Index(i), "", "", true})
+
+		}
+	}
+
+	return changes
+}
+
+// diffPorts compares the ports of a rule, an empty list allows every port.
+func diffPorts(old, p []networkingv1.NetworkPolicyPort, fldPath *field.Path) []policyChange {
+
+	var changes []policyChange
+
+	oldPorts, newPorts := portKeys(old), portKeys(p)
+
+	for i, k := range newPorts {
+		if !contains(oldPorts, k) {
+			changes = append(changes, policyChange{portAdded, fldPath.Index(i), "", k, len(old) > 0})
+		}
+	}
+
+	for _, k := range oldPorts {
+		if !contains(newPorts, k) {
+			changes = append(changes, policyChange{portRemoved, fldPath, k, "", len(p) == 0})
+		}
+	}
+
+	return changes
+}
+
+// portKeys renders ports as protocol/port[-endPort]
+func portKeys(p []networkingv1.NetworkPolicyPort) []string {
+
+	keys := make([]string, len(p))
+
+	for i, e := range p {
+
+		protocol := "TCP"
+		if e.Protocol != nil {
+			protocol = string(*e.Protocol)
+		}
+
+		port := "*"
+		if e.Port != nil {
+			port = e.Port.String()
+		}
+
+		keys[i] = protocol + "/" + port
+		if e.EndPort != nil {
+			keys[i] += fmt.Sprintf("-%d", *e.EndPort)
+		}
+	}
+
+	return keys
+}
+
+// diffIPBlock compares two ipBlocks, a CIDR is narrowed when the new one is
+// within the old one and widened otherwise. A removed except entry is
+// widening when its range is in the new CIDR and not excluded by another
+// entry.
+func diffIPBlock(old, p *networkingv1.IPBlock, fldPath *field.Path) []policyChange {
+
+	var changes []policyChange
+
+	_, oldCIDR, oldErr := net.ParseCIDR(old.CIDR)
+	_, newCIDR, newErr := net.ParseCIDR(p.CIDR)
+
+	if old.CIDR != p.CIDR {
+		if oldErr == nil && newErr == nil && containsNetwork(oldCIDR, newCIDR) {
+			changes = append(changes, policyChange{cidrNarrowed, fldPath.Child("cidr"), old.CIDR, p.CIDR, false})
+		} else {
+			changes = append(changes, policyChange{cidrWidened, fldPath.Child("cidr"), old.CIDR, p.CIDR, true})
+		}
+	}
+
+	for i, e := range p.Except {
+		if !contains(old.Except, e) {
+			changes = append(changes, policyChange{exceptAdded, fldPath.Child("except").Index(i), "", e, false})
+		}
+	}
+
+	for _, e := range old.Except {
+
+		if contains(p.Except, e) {
+			continue
+		}
+
+		widening := true
+		if _, n, err := net.ParseCIDR(e); err == nil && newErr == nil {
+			widening = overlapsNetwork(newCIDR, n) && !isExcluded(p.Except, n)
+		}
+
+		changes = append(changes, policyChange{exceptRemoved, fldPath.Child("except"), e, "", widening})
+	}
+
+	return changes
+}
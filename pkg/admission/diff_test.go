@@ -0,0 +1,204 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func newSpec(t *testing.T, s string) *networkingv1.NetworkPolicySpec {
+
+	p := &networkingv1.NetworkPolicySpec{}
+	if err := json.Unmarshal([]byte(s), p); err != nil {
+		t.Fatalf("%s: error %v", s, err)
+	}
+
+	return p
+}
+
+func TestDiffNetworkPolicySpec(t *testing.T) {
+
+	tests := []struct {
+		old      string
+		new      string
+		expected []string
+	}{
+		{
+			`{"podSelector": {"matchLabels": {"app": "web"}}}`,
+			`{"podSelector": {"matchLabels": {"app": "web"}}}`,
+			nil,
+		},
+		{
+			`{"podSelector": {"matchLabels": {"app": "web"}}}`,
+			`{"podSelector": {"matchLabels": {"app": "db"}}}`,
+			[]string{"PodSelectorChanged spec.podSelector true"},
+		},
+		{
+			`{"podSelector": {}, "policyTypes": ["Ingress", "Egress"]}`,
+			`{"podSelector": {}, "egress": [{}]}`,
+			[]string{"RuleAdded spec.egress[0] true"},
+		},
+		{
+			`{"podSelector": {}, "policyTypes": ["Ingress", "Egress"]}`,
+			`{"podSelector": {}, "policyTypes": ["Ingress"]}`,
+			[]string{"PolicyTypeRemoved spec.policyTypes true"},
+		},
+		{
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/24", "except": ["10.0.0.0/28", "10.0.0.16/28"]}}]}]}`,
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/16", "except": ["10.0.0.0/28", "10.0.0.32/28"]}}]}]}`,
+			[]string{
+				"CIDRWidened spec.ingress[0].from[0].ipBlock.cidr true",
+				"ExceptAdded spec.ingress[0].from[0].ipBlock.except[1] false",
+				"ExceptRemoved spec.ingress[0].from[0].ipBlock.except true",
+			},
+		},
+		{
+			`{"podSelector": {}, "egress": [{"to": [{"ipBlock": {"cidr": "10.0.0.0/16", "except": ["10.0.0.0/24", "10.1.0.0/24"]}}]}]}`,
+			`{"podSelector": {}, "egress": [{"to": [{"ipBlock": {"cidr": "10.0.128.0/17", "except": ["10.0.0.0/24"]}}]}]}`,
+			[]string{
+				"CIDRNarrowed spec.egress[0].to[0].ipBlock.cidr false",
+				"ExceptRemoved spec.egress[0].to[0].ipBlock.except false",
+			},
+		},
+		{
+			`{"podSelector": {}, "ingress": [{"from": [{"podSelector": {}}], "ports": [{"port": 80}]}]}`,
+			`{"podSelector": {}, "ingress": [{"from": [{"podSelector": {}}, {"namespaceSelector": {}}], "ports": [{"port": 443}]}, {}]}`,
+			[]string{
+				"PeerAdded spec.ingress[0].from[1] true",
+				"PortAdded spec.ingress[0].ports[0] true",
+				"PortRemoved spec.ingress[0].ports false",
+				"RuleAdded spec.ingress[1] true",
+			},
+		},
+		{
+			`{"podSelector": {}, "ingress": [{"from": [{"podSelector": {}}], "ports": [{"port": 80}]}, {}]}`,
+			`{"podSelector": {}, "ingress": [{"ports": [{"port": 80, "protocol": "TCP"}]}]}`,
+			[]string{
+				"PeerRemoved spec.ingress[0].from[0] true",
+				"RuleRemoved spec.ingress[1] false",
+			},
+		},
+		{
+			`{"podSelector": {}, "egress": [{"to": [{"podSelector": {"matchLabels": {"app": "web"}}}]}]}`,
+			`{"podSelector": {}, "egress": [{"to": [{"podSelector": {"matchLabels": {"app": "db"}}}], "ports": [{"port": 53, "protocol": "UDP"}]}]}`,
+			[]string{
+				"PeerChanged spec.egress[0].to[0] true",
+				"PortAdded spec.egress[0].ports[0] false",
+			},
+		},
+	}
+
+	for _, i := range tests {
+
+		changes := diffNetworkPolicySpec(newSpec(t, i.old), newSpec(t, i.new), field.NewPath("spec"))
+
+		if len(changes) != len(i.expected) {
+			t.Errorf("%s -> %s: changes were %v and expected are %v", i.old, i.new, changes, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if c := fmt.Sprintf("%s %s %v", changes[k].Type, changes[k].FldPath, changes[k].Widening); c != e {
+				t.Errorf("%s -> %s: change was %s and expected is %s", i.old, i.new, c, e)
+			}
+		}
+
+	}
+
+}
+
+func TestValidateUpdate(t *testing.T) {
+
+	config := `
+networkPolicyValidator:
+  updates:
+    rules:
+    - name: "PodSelectorChange"
+      operator: "In"
+      values: ["false"]
+    - name: "CIDRWidening"
+      operator: "In"
+      values: ["false"]
+    - name: "ExceptRemoval"
+      operator: "NotIn"
+      values: ["true"]
+profiles:
+- name: tighten
+  namespaces:
+  - locked
+  networkPolicyValidator:
+    updates:
+      rules:
+      - name: "Widening"
+        operator: "In"
+        values: ["false"]
+`
+
+	tests := []struct {
+		namespace string
+		old       string
+		new       string
+		expected  []string
+	}{
+		{
+			"default",
+			`{"podSelector": {"matchLabels": {"app": "web"}}}`,
+			`{"podSelector": {"matchLabels": {"app": "db"}}}`,
+			[]string{"spec.podSelector"},
+		},
+		{
+			"default",
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/24", "except": ["10.0.0.0/28"]}}]}]}`,
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/16"}}]}]}`,
+			[]string{"spec.ingress[0].from[0].ipBlock.cidr", "spec.ingress[0].from[0].ipBlock.except"},
+		},
+		{
+			"default",
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/16", "except": ["10.0.0.0/28"]}}]}]}`,
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/24", "except": ["10.0.0.0/27"]}}]}, {}]}`,
+			nil,
+		},
+		{
+			"locked",
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/16", "except": ["10.0.0.0/28"]}}]}]}`,
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/24", "except": ["10.0.0.0/27"]}}]}, {}]}`,
+			[]string{"spec.ingress[1]"},
+		},
+		{
+			"locked",
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/16"}}]}, {}]}`,
+			`{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/24", "except": ["10.0.0.0/27"]}}]}]}`,
+			nil,
+		},
+	}
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		old := &networkingv1.NetworkPolicy{Spec: *newSpec(t, i.old)}
+		p := &networkingv1.NetworkPolicy{Spec: *newSpec(t, i.new)}
+		old.Namespace, p.Namespace = i.namespace, i.namespace
+
+		l := v.ValidateUpdate(old, p, nil)
+
+		if len(l) != len(i.expected) {
+			t.Errorf("%s: %s -> %s: violations were %v and expected are %v", i.namespace, i.old, i.new, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if l[k].Field != e || l[k].Enforcement != EnforcementEnforce {
+				t.Errorf("%s: %s -> %s: violation was %v and expected field is %s", i.namespace, i.old, i.new, l[k], e)
+			}
+		}
+
+	}
+
+}
@@ -0,0 +1,127 @@
+package admission
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateObject checks object u of resource gvr with the field validators
+// of the profile of its namespace that select gvr.
+func (v *NetworkAdmissionValidator) ValidateObject(u *unstructured.Unstructured, gvr metav1.GroupVersionResource, namespaceLabels map[string]string) ViolationList {
+
+	validators := v.validators(u.GetNamespace(), namespaceLabels)
+
+	var errs []error
+
+	for i := range validators.FieldValidators {
+
+		f := &validators.FieldValidators[i]
+		if !f.matches(gvr) {
+			continue
+		}
+
+		if ok, err := f.isValid(u.UnstructuredContent()); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	if ok, err := aggregate(errs); !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement)
+	}
+
+	return nil
+
+}
+
+// FieldValidator checks the values selected by a field selector in the
+// objects of any resource, e.g. spec.template.spec.containers[*].image.
+// APIGroups and Resources select the resources the rules apply to, * matches
+// every resource and an empty APIGroups list matches every group.
+type FieldValidator struct {
+	APIGroups []string `json:"apiGroups,omitempty"`
+	Resources []string `json:"resources"`
+	Path      string   `json:"path"`
+	Rules     []Rule   `json:"rules"`
+
+	steps []selectorStep
+}
+
+// supported rules to check
+// FieldValue
+// ListSize
+func (v *FieldValidator) supportedRules() []RuleName {
+	return []RuleName{FieldValue, ListSize}
+}
+
+// compile parses the field selector of the validator
+func (v *FieldValidator) compile() error {
+
+	if len(v.Resources) == 0 {
+		return fmt.Errorf("resources are required")
+	}
+
+	steps, err := parseSelector(v.Path)
+	if err != nil {
+		return fmt.Errorf("invalid path: %v", err)
+	}
+	v.steps = steps
+
+	return nil
+}
+
+func (v *FieldValidator) matches(gvr metav1.GroupVersionResource) bool {
+
+	if len(v.APIGroups) > 0 && !contains(v.APIGroups, gvr.Group) && !contains(v.APIGroups, "*") {
+		return false
+	}
+
+	return contains(v.Resources, gvr.Resource) || contains(v.Resources, "*")
+}
+
+func (v *FieldValidator) isValid(obj map[string]interface{}) (bool, error) {
+
+	var errs []error
+	values := selectValues(obj, v.steps)
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ListSize:
+
+			if ok, err := r.isValidListSize(len(values), field.NewPath(v.Path)); !ok {
+				errs = append(errs, err)
+			}
+
+		case FieldValue:
+
+			for _, i := range values {
+				if ok, err := r.isValidFieldValue(i); !ok {
+					errs = append(errs, err)
+				}
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// isValidFieldValue checks a selected value, only scalar values are accepted
+func (v *Rule) isValidFieldValue(s selectorValue) (bool, error) {
+
+	value, ok := scalarString(s.value)
+	if !ok {
+		return false, v.violation(s.fldPath, "", fmt.Sprintf(
+			"error InvalidFieldValue: %s is not a value", s.fldPath))
+	}
+
+	return v.check(value, s.fldPath, "InvalidFieldValue", "field "+s.fldPath.String())
+
+}
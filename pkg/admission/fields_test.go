@@ -0,0 +1,134 @@
+package admission
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestValidateObject(t *testing.T) {
+
+	config := `
+fieldValidators:
+- apiGroups: ["apps"]
+  resources: ["deployments"]
+  path: "spec.template.spec.containers[*].image"
+  rules:
+  - name: "FieldValue"
+    operator: "Matches"
+    value: "gcr.io/.*"
+- apiGroups: ["example.com"]
+  resources: ["databases"]
+  path: "spec.replicas"
+  rules:
+  - name: "ListSize"
+    operator: "Equals"
+    value: 1
+  - name: "FieldValue"
+    operator: "Ge"
+    value: 3
+profiles:
+- name: dev
+  namespaces:
+  - dev
+  enforcement: warn
+  fieldValidators:
+  - resources: ["*"]
+    path: "metadata.labels['owner']"
+    rules:
+    - name: "ListSize"
+      operator: "Equals"
+      value: 1
+`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	deployments := metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	databases := metav1.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "databases"}
+
+	tests := []struct {
+		gvr       metav1.GroupVersionResource
+		namespace string
+		object    map[string]interface{}
+		expected  []string
+	}{
+		{
+			deployments, "default",
+			map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"image": "gcr.io/shop/app:v1"},
+					map[string]interface{}{"image": "envoy:v1"},
+				},
+			}}}},
+			[]string{"spec.template.spec.containers[1].image"},
+		},
+		{
+			databases, "default",
+			map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			[]string{"spec.replicas"},
+		},
+		{
+			databases, "default",
+			map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(3)}},
+			nil,
+		},
+		{
+			databases, "default",
+			map[string]interface{}{"spec": map[string]interface{}{}},
+			[]string{"spec.replicas"},
+		},
+		{
+			databases, "default",
+			map[string]interface{}{"spec": map[string]interface{}{"replicas": map[string]interface{}{}}},
+			[]string{"spec.replicas"},
+		},
+		{
+			databases, "dev",
+			map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			[]string{"metadata.labels['owner']"},
+		},
+	}
+
+	for _, i := range tests {
+
+		u := &unstructured.Unstructured{Object: i.object}
+		u.SetNamespace(i.namespace)
+
+		l := v.ValidateObject(u, i.gvr, nil)
+
+		if len(l) != len(i.expected) {
+			t.Errorf("%s %v: violations were %v and expected are %v", i.gvr, i.object, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if l[k].Field != e {
+				t.Errorf("%s %v: violation was %v and expected field is %s", i.gvr, i.object, l[k], e)
+			}
+		}
+
+	}
+
+}
+
+func TestFieldValidatorErrors(t *testing.T) {
+
+	tests := []string{
+		"fieldValidators:\n- resources: [pods]\n  path: 'spec..containers'\n",
+		"fieldValidators:\n- path: 'spec'\n",
+		"profiles:\n- name: a\n  fieldValidators:\n  - resources: [pods]\n    path: 'spec.containers['\n",
+	}
+
+	for _, i := range tests {
+
+		if v, err := parseAdmissionValidator([]byte(i)); err == nil {
+			t.Errorf("%q: expected an error, got validator %+v", i, v)
+		}
+
+	}
+
+}
@@ -0,0 +1,241 @@
+package admission
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateIngress checks the hosts, TLS entries and paths of ingress p with
+// the ingress rules of the profile of its namespace.
+func (v *NetworkAdmissionValidator) ValidateIngress(p *networkingv1.Ingress, namespaceLabels map[string]string) ViolationList {
+
+	validators := v.validators(p.Namespace, namespaceLabels)
+
+	if ok, err := validators.IngressValidator.isValid(&p.Spec, field.NewPath("spec")); !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement)
+	}
+
+	return nil
+
+}
+
+// IngressValidator provides the specification of an Ingress
+type IngressValidator struct {
+	Rules []Rule       `json:"rules,omitempty"`
+	Host  IngressHost  `json:"host,omitempty"`
+	Paths IngressPaths `json:"paths,omitempty"`
+}
+
+// supported rules to check
+// RequireTLS
+func (v *IngressValidator) supportedRules() []RuleName {
+	return []RuleName{RequireTLS}
+}
+
+func (v *IngressValidator) isValid(p *networkingv1.IngressSpec, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, i := range p.Rules {
+
+		if ok, err := v.Host.isValid(i.Host, fldPath.Child("rules").Index(k).Child("host")); !ok {
+			errs = append(errs, err)
+		}
+
+		var paths []networkingv1.HTTPIngressPath
+		if i.HTTP != nil {
+			paths = i.HTTP.Paths
+		}
+
+		if ok, err := v.Paths.isValid(paths, fldPath.Child("rules").Index(k).Child("http", "paths")); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	for k, i := range p.TLS {
+		for j, h := range i.Hosts {
+
+			if ok, err := v.Host.isValid(h, fldPath.Child("tls").Index(k).Child("hosts").Index(j)); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case RequireTLS:
+
+			for k, i := range p.Rules {
+				if ok, err := r.isValidTLS(i.Host, p.TLS, fldPath.Child("rules").Index(k).Child("host")); !ok {
+					errs = append(errs, err)
+				}
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// IngressHost checks the hosts of the rules and TLS entries of an Ingress.
+// An empty host matches every host and is handled as a wildcard.
+type IngressHost struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// DomainSuffix
+// WildcardHosts
+func (v *IngressHost) supportedRules() []RuleName {
+	return []RuleName{DomainSuffix, WildcardHosts}
+}
+
+func (v *IngressHost) isValid(host string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case DomainSuffix:
+
+			if ok, err := r.isValidDomainSuffix(host, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case WildcardHosts:
+
+			if ok, err := r.check(strconv.FormatBool(isWildcardHost(host)), fldPath, "InvalidHost", "wildcard host"); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// IngressPaths checks the HTTP paths of each rule of an Ingress
+type IngressPaths struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// ListSize
+func (v *IngressPaths) supportedRules() []RuleName {
+	return []RuleName{ListSize}
+}
+
+func (v *IngressPaths) isValid(p []networkingv1.HTTPIngressPath, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ListSize:
+
+			if ok, err := r.isValidListSize(len(p), fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// isValidDomainSuffix checks that host is a subdomain of one of the rule
+// domains for In and of none of them for NotIn.
+func (v *Rule) isValidDomainSuffix(host string, fldPath *field.Path) (bool, error) {
+
+	within := false
+	if host != "" {
+		for _, d := range v.Values {
+			if hasDomainSuffix(host, d) {
+				within = true
+				break
+			}
+		}
+	}
+
+	if within != (v.Operator == OpIn) {
+		return false, v.violation(fldPath, host, fmt.Sprintf(
+			"error InvalidHost: host must have a domain suffix %s %v", v.Operator, v.expected()))
+	}
+
+	return true, nil
+
+}
+
+// isValidTLS checks that host is listed in a TLS entry when it is selected
+// by the rule: listed in its values for In or matching its pattern for
+// Matches.
+func (v *Rule) isValidTLS(host string, tls []networkingv1.IngressTLS, fldPath *field.Path) (bool, error) {
+
+	selected, err := operatorExec(host, v.operand(), v.Operator)
+	if err != nil {
+		return false, v.violation(fldPath, host, err.Error())
+	}
+
+	if !selected {
+		return true, nil
+	}
+
+	for _, i := range tls {
+		for _, h := range i.Hosts {
+			if coversHost(h, host) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, v.violation(fldPath, host, fmt.Sprintf(
+		"error MissingTLS: host %s must be listed in spec.tls", host))
+
+}
+
+// coversHost returns true when the TLS host h is host or a wildcard matching
+// it, a wildcard only matches a single label.
+func coversHost(h, host string) bool {
+
+	if h == host {
+		return true
+	}
+
+	if !strings.HasPrefix(h, "*.") || isWildcardHost(host) {
+		return false
+	}
+
+	i := strings.Index(host, ".")
+	return i > 0 && host[i+1:] == h[2:]
+}
+
+// isWildcardHost returns true for hosts matching more than one name
+func isWildcardHost(host string) bool {
+
+	return host == "" || strings.HasPrefix(host, "*")
+}
+
+// hasDomainSuffix returns true when host is domain or one of its subdomains
+func hasDomainSuffix(host, domain string) bool {
+
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	host = strings.TrimPrefix(strings.ToLower(host), "*.")
+
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
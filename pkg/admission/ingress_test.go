@@ -0,0 +1,216 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestIngressHost(t *testing.T) {
+
+	tests := []struct {
+		rules    string
+		expected bool
+		host     string
+	}{
+		{`{ "rules": [
+			{
+				"name": "DomainSuffix",
+				"operator": "In",
+				"values": ["apps.example.com", "example.org"]
+			}
+		]}`,
+			true,
+			"shop.apps.example.com",
+		},
+		{`{ "rules": [
+			{
+				"name": "DomainSuffix",
+				"operator": "In",
+				"values": ["apps.example.com", "example.org"]
+			}
+		]}`,
+			true,
+			"example.org",
+		},
+		{`{ "rules": [
+			{
+				"name": "DomainSuffix",
+				"operator": "In",
+				"values": ["apps.example.com"]
+			}
+		]}`,
+			false,
+			"shopapps.example.com",
+		},
+		{`{ "rules": [
+			{
+				"name": "DomainSuffix",
+				"operator": "In",
+				"values": ["apps.example.com"]
+			}
+		]}`,
+			false,
+			"",
+		},
+		{`{ "rules": [
+			{
+				"name": "DomainSuffix",
+				"operator": "NotIn",
+				"values": ["internal.example.com"]
+			}
+		]}`,
+			false,
+			"*.internal.example.com",
+		},
+		{`{ "rules": [
+			{
+				"name": "WildcardHosts",
+				"operator": "In",
+				"values": ["false"]
+			}
+		]}`,
+			false,
+			"*.apps.example.com",
+		},
+		{`{ "rules": [
+			{
+				"name": "WildcardHosts",
+				"operator": "In",
+				"values": ["false"]
+			}
+		]}`,
+			true,
+			"shop.apps.example.com",
+		},
+	}
+
+	for _, i := range tests {
+
+		a := IngressHost{}
+		if err := json.Unmarshal([]byte(i.rules), &a); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		result, err := a.isValid(i.host, field.NewPath("host"))
+
+		if result != i.expected {
+			t.Errorf("%q: result was %v and expected is %v: %v", i.host, result, i.expected, err)
+		}
+
+	}
+
+}
+
+func TestCoversHost(t *testing.T) {
+
+	tests := []struct {
+		tls      string
+		host     string
+		expected bool
+	}{
+		{"shop.example.com", "shop.example.com", true},
+		{"*.example.com", "shop.example.com", true},
+		{"*.example.com", "a.shop.example.com", false},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "*.example.com", true},
+		{"*.example.com", "", false},
+	}
+
+	for _, i := range tests {
+
+		if result := coversHost(i.tls, i.host); result != i.expected {
+			t.Errorf("%s covers %q: result was %v and expected is %v", i.tls, i.host, result, i.expected)
+		}
+
+	}
+
+}
+
+func TestValidateIngress(t *testing.T) {
+
+	config := `
+ingressValidator:
+  rules:
+  - name: "RequireTLS"
+    operator: "Matches"
+    value: ".*\\.example\\.com"
+  host:
+    rules:
+    - name: "DomainSuffix"
+      operator: "In"
+      values: ["example.com"]
+    - name: "WildcardHosts"
+      operator: "In"
+      values: ["false"]
+  paths:
+    rules:
+    - name: "ListSize"
+      operator: "Le"
+      value: 2
+profiles:
+- name: team-a
+  namespaces:
+  - team-a
+  ingressValidator:
+    host:
+      rules:
+      - name: "DomainSuffix"
+        operator: "In"
+        values: ["team-a.example.com"]
+`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	paths := `"http": { "paths": [ { "path": "/", "pathType": "Prefix", "backend": { "service": { "name": "web", "port": { "number": 80 } } } } ] }`
+
+	tests := []struct {
+		namespace string
+		spec      string
+		expected  []string
+	}{
+		{"default", `{ "tls": [ { "hosts": ["shop.example.com"] } ], "rules": [ { "host": "shop.example.com", ` + paths + ` } ] }`, nil},
+		{"default", `{ "tls": [ { "hosts": ["*.example.com"] } ], "rules": [ { "host": "shop.example.com", ` + paths + ` } ] }`,
+			[]string{"spec.tls[0].hosts[0]"}},
+		{"default", `{ "rules": [ { "host": "shop.example.com", ` + paths + ` } ] }`,
+			[]string{"spec.rules[0].host"}},
+		{"default", `{ "rules": [ { "host": "shop.example.org", ` + paths + ` } ] }`,
+			[]string{"spec.rules[0].host"}},
+		{"default", `{ "rules": [ { ` + paths + ` } ] }`,
+			[]string{"spec.rules[0].host", "spec.rules[0].host"}},
+		{"default", `{ "tls": [ { "hosts": ["shop.example.com"] } ], "rules": [ { "host": "shop.example.com", "http": { "paths": [ {}, {}, {} ] } } ] }`,
+			[]string{"spec.rules[0].http.paths"}},
+		{"team-a", `{ "rules": [ { "host": "shop.team-a.example.com", ` + paths + ` } ] }`, nil},
+		{"team-a", `{ "rules": [ { "host": "shop.team-b.example.com", ` + paths + ` } ] }`,
+			[]string{"spec.rules[0].host"}},
+	}
+
+	for _, i := range tests {
+
+		p := &networkingv1.Ingress{}
+		if err := json.Unmarshal([]byte(i.spec), &p.Spec); err != nil {
+			t.Fatalf("error %v", err)
+		}
+		p.Namespace = i.namespace
+
+		l := v.ValidateIngress(p, nil)
+
+		if len(l) != len(i.expected) {
+			t.Errorf("%s %s: violations were %v and expected are %v", i.namespace, i.spec, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if l[k].Field != e {
+				t.Errorf("%s %s: violation was %v and expected field is %s", i.namespace, i.spec, l[k], e)
+			}
+		}
+
+	}
+
+}
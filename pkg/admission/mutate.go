@@ -0,0 +1,211 @@
+package admission
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// PatchOperation is a JSONPatch operation (RFC 6902) returned by the
+// mutating webhook
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// NetworkPolicyMutator holds the defaults injected into NetworkPolicies
+// before they are validated
+type NetworkPolicyMutator struct {
+	// PolicyTypes are set on the policies without policy types
+	PolicyTypes []PolicyType `json:"defaultPolicyTypes,omitempty"`
+	// PodSelectorLabels are added to the pod selector when missing
+	PodSelectorLabels map[string]string `json:"podSelectorLabels,omitempty"`
+	// ExceptCIDRs are appended to the except list of the ipBlocks containing
+	// them, e.g. 169.254.169.254/32
+	ExceptCIDRs []string `json:"exceptCIDRs,omitempty"`
+	// Annotations are set on the policy metadata
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	exceptNetworks []*net.IPNet
+}
+
+// compile parses the except CIDRs of the mutator
+func (v *NetworkPolicyMutator) compile() error {
+
+	v.exceptNetworks = nil
+
+	for i, c := range v.ExceptCIDRs {
+
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return fmt.Errorf("exceptCIDRs[%d]: invalid CIDR %q", i, c)
+		}
+
+		v.exceptNetworks = append(v.exceptNetworks, n)
+	}
+
+	return nil
+}
+
+// Mutate injects the defaults of the profile bound to the policy namespace
+// into p and returns the JSONPatch operations doing the same on the
+// received object. namespaceLabels are the labels of that namespace.
+func (v *NetworkAdmissionValidator) Mutate(p *networkingv1.NetworkPolicy, namespaceLabels map[string]string) []PatchOperation {
+
+	m := &v.validators(p.Namespace, namespaceLabels).NetworkPolicyMutator
+
+	var ops []PatchOperation
+	ops = append(ops, m.mutateAnnotations(p)...)
+	ops = append(ops, m.mutatePolicyTypes(p)...)
+	ops = append(ops, m.mutatePodSelector(p)...)
+	ops = append(ops, m.mutateIPBlocks(p)...)
+
+	return ops
+}
+
+func (v *NetworkPolicyMutator) mutateAnnotations(p *networkingv1.NetworkPolicy) []PatchOperation {
+
+	if len(v.Annotations) == 0 {
+		return nil
+	}
+
+	if p.Annotations == nil {
+		p.Annotations = map[string]string{}
+		for k, a := range v.Annotations {
+			p.Annotations[k] = a
+		}
+		return []PatchOperation{{Op: "add", Path: "/metadata/annotations", Value: v.Annotations}}
+	}
+
+	var ops []PatchOperation
+
+	for _, k := range sortedKeys(v.Annotations) {
+
+		if a, ok := p.Annotations[k]; ok && a == v.Annotations[k] {
+			continue
+		}
+
+		p.Annotations[k] = v.Annotations[k]
+		ops = append(ops, PatchOperation{Op: "add", Path: "/metadata/annotations/" + escapePointer(k), Value: v.Annotations[k]})
+	}
+
+	return ops
+}
+
+func (v *NetworkPolicyMutator) mutatePolicyTypes(p *networkingv1.NetworkPolicy) []PatchOperation {
+
+	if len(v.PolicyTypes) == 0 || len(p.Spec.PolicyTypes) > 0 {
+		return nil
+	}
+
+	for _, t := range v.PolicyTypes {
+		p.Spec.PolicyTypes = append(p.Spec.PolicyTypes, networkingv1.PolicyType(t))
+	}
+
+	return []PatchOperation{{Op: "add", Path: "/spec/policyTypes", Value: p.Spec.PolicyTypes}}
+}
+
+func (v *NetworkPolicyMutator) mutatePodSelector(p *networkingv1.NetworkPolicy) []PatchOperation {
+
+	missing := map[string]string{}
+	for k, l := range v.PodSelectorLabels {
+		if _, ok := p.Spec.PodSelector.MatchLabels[k]; !ok {
+			missing[k] = l
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if p.Spec.PodSelector.MatchLabels == nil {
+		p.Spec.PodSelector.MatchLabels = missing
+		return []PatchOperation{{Op: "add", Path: "/spec/podSelector/matchLabels", Value: missing}}
+	}
+
+	var ops []PatchOperation
+
+	for _, k := range sortedKeys(missing) {
+		p.Spec.PodSelector.MatchLabels[k] = missing[k]
+		ops = append(ops, PatchOperation{Op: "add", Path: "/spec/podSelector/matchLabels/" + escapePointer(k), Value: missing[k]})
+	}
+
+	return ops
+}
+
+func (v *NetworkPolicyMutator) mutateIPBlocks(p *networkingv1.NetworkPolicy) []PatchOperation {
+
+	if len(v.exceptNetworks) == 0 {
+		return nil
+	}
+
+	var ops []PatchOperation
+
+	for i := range p.Spec.Ingress {
+		for j := range p.Spec.Ingress[i].From {
+			ops = append(ops, v.mutateIPBlock(p.Spec.Ingress[i].From[j].IPBlock, fmt.Sprintf("/spec/ingress/%d/from/%d/ipBlock", i, j))...)
+		}
+	}
+
+	for i := range p.Spec.Egress {
+		for j := range p.Spec.Egress[i].To {
+			ops = append(ops, v.mutateIPBlock(p.Spec.Egress[i].To[j].IPBlock, fmt.Sprintf("/spec/egress/%d/to/%d/ipBlock", i, j))...)
+		}
+	}
+
+	return ops
+}
+
+// mutateIPBlock appends the except CIDRs strictly within the ipBlock CIDR
+// that are not already excluded by one of its except entries.
+func (v *NetworkPolicyMutator) mutateIPBlock(b *networkingv1.IPBlock, path string) []PatchOperation {
+
+	if b == nil {
+		return nil
+	}
+
+	_, cidr, err := net.ParseCIDR(b.CIDR)
+	if err != nil {
+		return nil
+	}
+
+	var ops []PatchOperation
+
+	for k, n := range v.exceptNetworks {
+
+		if !containsNetwork(cidr, n) || sameNetwork(cidr, n) || isExcluded(b.Except, n) {
+			continue
+		}
+
+		if b.Except == nil {
+			ops = append(ops, PatchOperation{Op: "add", Path: path + "/except", Value: []string{v.ExceptCIDRs[k]}})
+		} else {
+			ops = append(ops, PatchOperation{Op: "add", Path: path + "/except/-", Value: v.ExceptCIDRs[k]})
+		}
+
+		b.Except = append(b.Except, v.ExceptCIDRs[k])
+	}
+
+	return ops
+}
+
+// escapePointer escapes s to be used as a JSON pointer token (RFC 6901)
+func escapePointer(s string) string {
+
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func sortedKeys(m map[string]string) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
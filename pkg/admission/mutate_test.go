@@ -0,0 +1,187 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const mutatorConfig = `
+networkPolicyMutator:
+  defaultPolicyTypes:
+  - Ingress
+  - Egress
+  podSelectorLabels:
+    team: "a"
+  exceptCIDRs:
+  - "169.254.169.254/32"
+  annotations:
+    example.com/mutated: "true"
+networkPolicyValidator:
+  allowedPolicyTypes:
+  - Ingress
+  - Egress
+  podSelector:
+    matchLabels:
+      rules:
+      - name: "LabelValues"
+        operator: "In"
+        key: "team"
+        values: ["a"]
+  egress:
+    to:
+      ipBlock:
+        except:
+          rules:
+          - name: "ListSize"
+            operator: "Ge"
+            value: 1
+profiles:
+- name: open
+  namespaces:
+  - open
+`
+
+func TestMutate(t *testing.T) {
+
+	tests := []struct {
+		namespace string
+		policy    string
+		expected  []string
+	}{
+		{
+			"default",
+			`{"spec": {"podSelector": {}, "egress": [{"to": [{"ipBlock": {"cidr": "0.0.0.0/0"}}]}]}}`,
+			[]string{"/metadata/annotations", "/spec/policyTypes", "/spec/podSelector/matchLabels", "/spec/egress/0/to/0/ipBlock/except"},
+		},
+		{
+			"default",
+			`{"metadata": {"annotations": {"owner": "a"}}, "spec": {"podSelector": {"matchLabels": {"app": "web"}}, "policyTypes": ["Egress"],
+			  "egress": [{"to": [{"ipBlock": {"cidr": "169.254.0.0/16", "except": ["169.254.1.0/24"]}}, {"ipBlock": {"cidr": "10.0.0.0/8"}}]}]}}`,
+			[]string{"/metadata/annotations/example.com~1mutated", "/spec/podSelector/matchLabels/team", "/spec/egress/0/to/0/ipBlock/except/-"},
+		},
+		{
+			"default",
+			`{"metadata": {"annotations": {"example.com/mutated": "true"}}, "spec": {"podSelector": {"matchLabels": {"team": "b"}}, "policyTypes": ["Ingress"],
+			  "ingress": [{"from": [{"ipBlock": {"cidr": "169.254.0.0/16", "except": ["169.254.169.0/24"]}}, {"ipBlock": {"cidr": "169.254.169.254/32"}}]}]}}`,
+			nil,
+		},
+		{
+			"open",
+			`{"spec": {"podSelector": {}, "egress": [{"to": [{"ipBlock": {"cidr": "0.0.0.0/0"}}]}]}}`,
+			nil,
+		},
+	}
+
+	v, err := parseAdmissionValidator([]byte(mutatorConfig))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		p := &networkingv1.NetworkPolicy{}
+		if err := json.Unmarshal([]byte(i.policy), p); err != nil {
+			t.Fatalf("%s: error %v", i.policy, err)
+		}
+		p.Namespace = i.namespace
+
+		ops := v.Mutate(p, nil)
+
+		if len(ops) != len(i.expected) {
+			t.Errorf("%s: operations were %v and expected are %v", i.policy, ops, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if ops[k].Op != "add" || ops[k].Path != e {
+				t.Errorf("%s: operation was %v and expected path is %s", i.policy, ops[k], e)
+			}
+		}
+
+	}
+
+}
+
+func TestMutateEmptyValues(t *testing.T) {
+
+	config := `
+networkPolicyMutator:
+  podSelectorLabels:
+    tier: ""
+  annotations:
+    example.com/reviewed: ""
+`
+	policy := `{"metadata": {"annotations": {"owner": "a"}}, "spec": {"podSelector": {"matchLabels": {"app": "web"}}}}`
+	expected := `[{"op":"add","path":"/metadata/annotations/example.com~1reviewed","value":""},{"op":"add","path":"/spec/podSelector/matchLabels/tier","value":""}]`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	p := &networkingv1.NetworkPolicy{}
+	if err := json.Unmarshal([]byte(policy), p); err != nil {
+		t.Fatalf("%s: error %v", policy, err)
+	}
+
+	patch, err := json.Marshal(v.Mutate(p, nil))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	if string(patch) != expected {
+		t.Errorf("patch was %s and expected is %s", patch, expected)
+	}
+
+}
+
+func TestMutateThenValidate(t *testing.T) {
+
+	v, err := parseAdmissionValidator([]byte(mutatorConfig))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	p := &networkingv1.NetworkPolicy{}
+	policy := `{"spec": {"podSelector": {}, "egress": [{"to": [{"ipBlock": {"cidr": "0.0.0.0/0"}}]}]}}`
+	if err := json.Unmarshal([]byte(policy), p); err != nil {
+		t.Fatalf("error %v", err)
+	}
+	p.Namespace = "default"
+
+	if l := v.Validate(p, nil); len(l) != 2 {
+		t.Errorf("violations before mutation were %v and expected are 2", l)
+	}
+
+	v.Mutate(p, nil)
+
+	if l := v.Validate(p, nil); len(l) != 0 {
+		t.Errorf("violations after mutation were %v and expected are none", l)
+	}
+
+	if ops := v.Mutate(p, nil); len(ops) != 0 {
+		t.Errorf("operations of a mutated policy were %v and expected are none", ops)
+	}
+
+}
+
+func TestMutatorErrors(t *testing.T) {
+
+	tests := []string{
+		"networkPolicyMutator:\n  exceptCIDRs: ['169.254.169.254']\n",
+		"profiles:\n- name: a\n  networkPolicyMutator:\n    exceptCIDRs: ['10.0.0.0/33']\n",
+		"networkPolicyMutator:\n  defaultPolicyTypes: [Both]\n",
+		"networkPolicyMutator:\n  podSelectorLabels:\n    team: 1\n",
+	}
+
+	for _, i := range tests {
+
+		if v, err := parseAdmissionValidator([]byte(i)); err == nil {
+			t.Errorf("%q: expected an error, got validator %+v", i, v)
+		}
+
+	}
+
+}
@@ -0,0 +1,33 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// NamespaceLabels maps namespace names to their labels
+type NamespaceLabels map[string]map[string]string
+
+// ParseNamespaceLabels parses namespace labels content b, read from file c,
+// e.g.
+//
+//	team-a:
+//	  env: production
+//	team-b:
+//	  env: staging
+func ParseNamespaceLabels(b []byte, c string) (NamespaceLabels, error) {
+
+	jsonFile, err := yaml.ToJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse namespace labels file %s: %v", c, err)
+	}
+
+	l := NamespaceLabels{}
+	if err := json.Unmarshal(jsonFile, &l); err != nil {
+		return nil, fmt.Errorf("unable to parse namespace labels file %s: %v", c, err)
+	}
+
+	return l, nil
+}
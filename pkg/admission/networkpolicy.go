@@ -4,57 +4,131 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 
-	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
-// NetworkAdmissionValidator is a NetworkPolicy abstraction to isValid objects
+// NetworkAdmissionValidator is a NetworkPolicy abstraction to isValid objects.
+// The top level Validators apply to the namespaces without a profile when
+// DefaultProfile is not set.
 type NetworkAdmissionValidator struct {
-	NetworkPolicyValidator NetworkPolicyValidator `json:"networkPolicyValidator,omitempty"`
+	Validators
+	Profiles       []Profile `json:"profiles,omitempty"`
+	DefaultProfile string    `json:"defaultProfile,omitempty"`
 }
 
-// IsValid will compare a received network policy object with NetworkadmissionRules.
-func (v *NetworkAdmissionValidator) IsValid(p *networkingv1.NetworkPolicy) (bool, error) {
+// Validate compares a received network policy object with the rules of the
+// profile bound to its namespace, namespaceLabels are the labels of that
+// namespace. It returns every violation found in the policy spec along with
+// its enforcement mode.
+func (v *NetworkAdmissionValidator) Validate(p *networkingv1.NetworkPolicy, namespaceLabels map[string]string) ViolationList {
 
-	if ok, err := v.NetworkPolicyValidator.isValid(&p.Spec); !ok {
+	validators := v.validators(p.Namespace, namespaceLabels)
 
-		return false, err
+	if ok, err := validators.NetworkPolicyValidator.isValid(&p.Spec, field.NewPath("spec")); !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement)
+	}
+
+	return nil
+
+}
+
+// ValidateUpdate compares the spec of an updated network policy p with the
+// spec of its previous version old and returns the violations of the update
+// rules of the profile bound to its namespace.
+func (v *NetworkAdmissionValidator) ValidateUpdate(old, p *networkingv1.NetworkPolicy, namespaceLabels map[string]string) ViolationList {
+
+	validators := v.validators(p.Namespace, namespaceLabels)
+	changes := diffNetworkPolicySpec(&old.Spec, &p.Spec, field.NewPath("spec"))
 
+	if ok, err := validators.NetworkPolicyValidator.Updates.isValid(changes); !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement)
+	}
+
+	return nil
+
+}
+
+// IsValid will compare a received network policy object with NetworkadmissionRules.
+// The policy is not valid when it has violations in enforce mode, the
+// returned error is then a ViolationList holding those violations.
+func (v *NetworkAdmissionValidator) IsValid(p *networkingv1.NetworkPolicy, namespaceLabels map[string]string) (bool, error) {
+
+	if l := v.Validate(p, namespaceLabels).Filter(EnforcementEnforce); len(l) > 0 {
+		return false, l
 	}
 
 	return true, nil
 
 }
 
-//NewAdmissionValidator creates a new admission validator
-func NewAdmissionValidator(c string) *NetworkAdmissionValidator {
+// NewAdmissionValidator creates a new admission validator from the rules in
+// config file c. The returned validator is never modified afterwards so it
+// can be shared by concurrent admission requests.
+func NewAdmissionValidator(c string) (*NetworkAdmissionValidator, error) {
+
+	byteValue, err := readConfigFile(c)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := os.Stat(c)
+	validator, err := parseAdmissionValidator(byteValue)
 	if err != nil {
-		glog.Error(fmt.Errorf("Config file is missing: %s ", c))
+		return nil, fmt.Errorf("unable to parse config file %s: %v", c, err)
 	}
 
+	return validator, nil
+
+}
+
+func readConfigFile(c string) ([]byte, error) {
+
 	yamlFile, err := os.Open(c)
 	if err != nil {
-		glog.Error(err)
+		return nil, fmt.Errorf("unable to open config file %s: %v", c, err)
 	}
 
 	defer yamlFile.Close()
-	byteValue, _ := ioutil.ReadAll(yamlFile)
-	jsonFile, err := yaml.ToJSON(byteValue)
+	byteValue, err := ioutil.ReadAll(yamlFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s: %v", c, err)
+	}
+
+	return byteValue, nil
+}
+
+func parseAdmissionValidator(b []byte) (*NetworkAdmissionValidator, error) {
+
+	if err := checkConfig(b); err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := yaml.ToJSON(b)
 	if err != nil {
-		glog.Error(err)
+		return nil, err
 	}
 
 	validator := &NetworkAdmissionValidator{}
-	json.Unmarshal(jsonFile, &validator)
+	if err := json.Unmarshal(jsonFile, validator); err != nil {
+		return nil, err
+	}
 
-	return validator
+	if err := validator.compile(); err != nil {
+		return nil, err
+	}
 
+	return validator, nil
 }
 
 // PolicyType is a List of allowed Policies type, e.g Ingress or Egress
@@ -73,47 +147,109 @@ type NetworkPolicyValidator struct {
 	Egress      NetworkPolicyEgressRule  `json:"egress,omitempty"`
 	PolicyTypes []PolicyType             `json:"allowedPolicyTypes,omitempty"`
 	PodSelector PodSelector              `json:"podSelector,omitempty"`
+	Updates     PolicyUpdates            `json:"updates,omitempty"`
 }
 
-func (v *NetworkPolicyValidator) isValid(p *networkingv1.NetworkPolicySpec) (bool, error) {
+// allowedPolicyTypes names the violations of NetworkPolicyValidator.PolicyTypes
+const allowedPolicyTypes RuleName = "AllowedPolicyTypes"
 
-	if ok, err := v.PodSelector.isValid(&p.PodSelector); !ok {
-		return false, err
+func (v *NetworkPolicyValidator) isValid(p *networkingv1.NetworkPolicySpec, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	if ok, err := v.PodSelector.isValid(&p.PodSelector, fldPath.Child("podSelector")); !ok {
+		errs = append(errs, err)
 	}
 
-	if ok, err := v.isValidPolicyTypes(&p.PolicyTypes); !ok {
-		return false, err
+	if ok, err := v.isValidPolicyTypes(&p.PolicyTypes, fldPath.Child("policyTypes")); !ok {
+		errs = append(errs, err)
 	}
 
-	if ok, err := v.Egress.isValid(&p.Egress); !ok {
-		return false, err
+	if ok, err := v.Egress.isValid(&p.Egress, fldPath.Child("egress")); !ok {
+		errs = append(errs, err)
 	}
 
-	if ok, err := v.Ingress.isValid(&p.Ingress); !ok {
-		return false, err
+	if ok, err := v.Ingress.isValid(&p.Ingress, fldPath.Child("ingress")); !ok {
+		errs = append(errs, err)
 	}
 
-	return true, nil
+	return aggregate(errs)
+
+}
+
+func (v *NetworkPolicyValidator) isValidPolicyTypes(n *[]networkingv1.PolicyType, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+	allowed := make([]string, len(v.PolicyTypes))
+	for k, j := range v.PolicyTypes {
+		allowed[k] = string(j)
+	}
+
+	for k, i := range *n {
+
+		if !contains(allowed, string(i)) {
+			errs = append(errs, &Violation{
+				Field:    fldPath.Index(k).String(),
+				Rule:     allowedPolicyTypes,
+				Operator: OpIn,
+				Expected: "[" + strings.Join(allowed, ", ") + "]",
+				Actual:   string(i),
+				Message:  fmt.Sprintf("PolicyType %s is not allowed for this namespace", string(i)),
+			})
+		}
+	}
+
+	return aggregate(errs)
+}
+
+// PolicyUpdates restricts the changes made to a NetworkPolicy when it is
+// updated
+type PolicyUpdates struct {
+	Rules []Rule `json:"rules"`
+}
 
+// supported rules to check
+// PodSelectorChange
+// CIDRWidening
+// ExceptRemoval
+// Widening
+func (v *PolicyUpdates) supportedRules() []RuleName {
+	return []RuleName{PodSelectorChange, CIDRWidening, ExceptRemoval, Widening}
 }
 
-func (v *NetworkPolicyValidator) isValidPolicyTypes(n *[]networkingv1.PolicyType) (bool, error) {
+func (v *PolicyUpdates) isValid(changes []policyChange) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		for _, c := range changes {
+
+			switch {
+
+			case r.Name == PodSelectorChange && c.Type == podSelectorChanged:
+
+			case r.Name == CIDRWidening && c.Type == cidrWidened:
 
-	for _, i := range *n {
-		var c bool
-		for _, j := range v.PolicyTypes {
+			case r.Name == ExceptRemoval && c.Type == exceptRemoved && c.Widening:
 
-			if string(i) == string(j) {
+			case r.Name == Widening && c.Widening:
 
-				c = true
+			default:
+
+				continue
 
 			}
-		}
-		if !c {
-			return false, fmt.Errorf("PolicyTpe %s is not allowed for this namespace", string(i))
+
+			if ok, err := r.isValidChange(c); !ok {
+				errs = append(errs, err)
+			}
+
 		}
 	}
-	return true, nil
+
+	return aggregate(errs)
+
 }
 
 // NetworkPolicyIngressRule describes a particular set of traffic that is allowed to the pods
@@ -122,21 +258,23 @@ type NetworkPolicyIngressRule struct {
 	From  NetworkPolicyPeer `json:"from,omitempty"`
 }
 
-func (v *NetworkPolicyIngressRule) isValid(p *[]networkingv1.NetworkPolicyIngressRule) (bool, error) {
+func (v *NetworkPolicyIngressRule) isValid(p *[]networkingv1.NetworkPolicyIngressRule, fldPath *field.Path) (bool, error) {
 
-	for _, e := range *p {
+	var errs []error
 
-		if ok, err := v.From.isValid(e.From); !ok {
-			return false, err
+	for i, e := range *p {
+
+		if ok, err := v.From.isValid(e.From, fldPath.Index(i).Child("from")); !ok {
+			errs = append(errs, err)
 		}
 
-		if ok, err := v.Ports.isValid(e.Ports); !ok {
-			return false, err
+		if ok, err := v.Ports.isValid(e.Ports, fldPath.Index(i).Child("ports")); !ok {
+			errs = append(errs, err)
 		}
 
 	}
 
-	return true, nil
+	return aggregate(errs)
 }
 
 // NetworkPolicyEgressRule describes a particular set of traffic that is allowed out of pods
@@ -145,21 +283,23 @@ type NetworkPolicyEgressRule struct {
 	To    NetworkPolicyPeer `json:"to,omitempty"`
 }
 
-func (v *NetworkPolicyEgressRule) isValid(p *[]networkingv1.NetworkPolicyEgressRule) (bool, error) {
+func (v *NetworkPolicyEgressRule) isValid(p *[]networkingv1.NetworkPolicyEgressRule, fldPath *field.Path) (bool, error) {
 
-	for _, e := range *p {
+	var errs []error
 
-		if ok, err := v.To.isValid(e.To); !ok {
-			return false, err
+	for i, e := range *p {
+
+		if ok, err := v.To.isValid(e.To, fldPath.Index(i).Child("to")); !ok {
+			errs = append(errs, err)
 		}
 
-		if ok, err := v.Ports.isValid(e.Ports); !ok {
-			return false, err
+		if ok, err := v.Ports.isValid(e.Ports, fldPath.Index(i).Child("ports")); !ok {
+			errs = append(errs, err)
 		}
 
 	}
 
-	return true, nil
+	return aggregate(errs)
 }
 
 // NetworkPolicyPort describes a port to allow traffic on
@@ -167,7 +307,26 @@ type NetworkPolicyPort struct {
 	Rules []Rule `json:"rules"`
 }
 
-func (v *NetworkPolicyPort) isValid(p []networkingv1.NetworkPolicyPort) (bool, error) {
+// supported rules to check
+// ListSize
+// PortNumber
+// Protocol
+// NamedPorts
+// PortName
+// PortRangeSize
+// AllPorts
+func (v *NetworkPolicyPort) supportedRules() []RuleName {
+	return []RuleName{ListSize, PortNumber, Protocol, NamedPorts, PortName, PortRangeSize, AllPorts}
+}
+
+func (v *NetworkPolicyPort) isValid(p []networkingv1.NetworkPolicyPort, fldPath *field.Path) (bool, error) {
+
+	// the rules can't be evaluated on reversed port ranges
+	if ok, err := isValidPortRanges(p, fldPath); !ok {
+		return false, err
+	}
+
+	var errs []error
 
 	for _, r := range v.Rules {
 
@@ -175,52 +334,299 @@ func (v *NetworkPolicyPort) isValid(p []networkingv1.NetworkPolicyPort) (bool, e
 
 		case ListSize:
 
-			if ok, err := r.isValidListSize(len(p)); err != nil || !ok {
-				return false, err
+			if ok, err := r.isValidListSize(len(p), fldPath); !ok {
+				errs = append(errs, err)
 			}
 
 		case PortNumber:
 
-			if ok, err := isValidPortNumber(p, r); err != nil || !ok {
-				return false, err
+			if ok, err := isValidPortNumber(p, r, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case Protocol:
+
+			if ok, err := isValidProtocol(p, r, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case NamedPorts:
+
+			if ok, err := isValidNamedPorts(p, r, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case PortName:
+
+			if ok, err := isValidPortName(p, r, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case PortRangeSize:
+
+			if ok, err := isValidPortRangeSize(p, r, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case AllPorts:
+
+			if ok, err := isValidAllPorts(p, r, fldPath); !ok {
+				errs = append(errs, err)
 			}
 
 		}
 	}
 
-	return true, nil
+	return aggregate(errs)
 
 }
 
-func isValidPortNumber(p []networkingv1.NetworkPolicyPort, r Rule) (bool, error) {
+// isValidPortRanges reports the numbered ports whose endPort is lower than
+// their port
+func isValidPortRanges(p []networkingv1.NetworkPolicyPort, fldPath *field.Path) (bool, error) {
 
-	for _, i := range p {
+	var errs []error
 
-		if ok, err := r.isValidPort(i.Port.IntValue()); !ok {
-			return false, err
+	for k, i := range p {
+
+		if i.Port == nil || i.Port.Type == intstr.String || i.EndPort == nil {
+			continue
+		}
+
+		if port := i.Port.IntValue(); int(*i.EndPort) < port {
+			errs = append(errs, invalidPortRange(fldPath.Index(k).Child("endPort"), port, int(*i.EndPort)))
 		}
 
 	}
-	return true, nil
+
+	return aggregate(errs)
+}
+
+// portRange returns the first and the last port allowed by p, a port without
+// a number allows every port. ok is false for named ports, their number is
+// only known on the pods.
+func portRange(p networkingv1.NetworkPolicyPort) (start, end int, ok bool) {
+
+	if p.Port == nil {
+		return 1, 65535, true
+	}
+
+	if p.Port.Type == intstr.String {
+		return 0, 0, false
+	}
+
+	start, end = p.Port.IntValue(), p.Port.IntValue()
+	if p.EndPort != nil && int(*p.EndPort) > start {
+		end = int(*p.EndPort)
+	}
+
+	return start, end, true
+}
+
+// isValidPortNumber checks the numbers of the ports, a range is checked as a
+// whole and named ports are skipped.
+func isValidPortNumber(p []networkingv1.NetworkPolicyPort, r Rule, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, i := range p {
+
+		start, end, ok := portRange(i)
+		if !ok {
+			continue
+		}
+
+		if ok, err := r.isValidPortRange(start, end, fldPath.Index(k).Child("port")); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+}
+
+// isValidProtocol checks the protocol of the ports, TCP when it is missing
+func isValidProtocol(p []networkingv1.NetworkPolicyPort, r Rule, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, i := range p {
+
+		protocol := string(corev1.ProtocolTCP)
+		if i.Protocol != nil {
+			protocol = string(*i.Protocol)
+		}
+
+		if ok, err := r.check(protocol, fldPath.Index(k).Child("protocol"), "InvalidProtocol", "protocol"); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+}
+
+// isValidNamedPorts checks whether the ports are named, the rule value is
+// "true" for a named port, e.g. NamedPorts In [false] forbids them.
+func isValidNamedPorts(p []networkingv1.NetworkPolicyPort, r Rule, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, i := range p {
+
+		named := i.Port != nil && i.Port.Type == intstr.String
+
+		if ok, err := r.check(strconv.FormatBool(named), fldPath.Index(k).Child("port"), "InvalidNamedPorts", "named port"); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+}
+
+// isValidPortName checks the names of the named ports, numbered ports are
+// skipped.
+func isValidPortName(p []networkingv1.NetworkPolicyPort, r Rule, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, i := range p {
+
+		if i.Port == nil || i.Port.Type != intstr.String {
+			continue
+		}
+
+		if ok, err := r.check(i.Port.StrVal, fldPath.Index(k).Child("port"), "InvalidPortName", "port name"); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+}
+
+// isValidPortRangeSize checks the number of ports allowed by each port, 1
+// for a single port, endPort - port + 1 for a range and 65535 for a port
+// without a number. Named ports are skipped.
+func isValidPortRangeSize(p []networkingv1.NetworkPolicyPort, r Rule, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, i := range p {
+
+		start, end, ok := portRange(i)
+		if !ok {
+			continue
+		}
+
+		path := fldPath.Index(k).Child("port")
+		if i.EndPort != nil {
+			path = fldPath.Index(k).Child("endPort")
+		}
+
+		if ok, err := r.check(end-start+1, path, "InvalidPortRangeSize", "port range size"); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+}
+
+// isValidAllPorts checks the ports allowing every port, the rule value is
+// "true" for an empty ports list, which allows every port and protocol, and
+// for a port without a number, which allows every port of its protocol, e.g.
+// AllPorts In [false] forbids both.
+func isValidAllPorts(p []networkingv1.NetworkPolicyPort, r Rule, fldPath *field.Path) (bool, error) {
+
+	if len(p) == 0 {
+		return r.check("true", fldPath, "InvalidAllPorts", "all ports")
+	}
+
+	var errs []error
+
+	for k, i := range p {
+
+		if ok, err := r.check(strconv.FormatBool(i.Port == nil), fldPath.Index(k), "InvalidAllPorts", "all ports"); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
 }
 
 // IPBlock describes a particular CIDR (Ex. "192.168.1.1/24") that is allowed to the pods
 type IPBlock struct {
+	Rules  []Rule `json:"rules,omitempty"`
 	CIDR   CIDR   `json:"cidr"`
 	Except Except `json:"except,omitempty"`
 }
 
-func (v *IPBlock) isValid(p *networkingv1.IPBlock) (bool, error) {
+// supported rules to check
+// ExceptWithinCIDR
+// OverlappingExcepts
+func (v *IPBlock) supportedRules() []RuleName {
+	return []RuleName{ExceptWithinCIDR, OverlappingExcepts}
+}
+
+func (v *IPBlock) isValid(p *networkingv1.IPBlock, fldPath *field.Path) (bool, error) {
 
-	if ok, err := v.CIDR.isValid(p); !ok {
+	// the rules can't be evaluated on malformed CIDRs
+	if ok, err := isValidIPBlockCIDRs(p, fldPath); !ok {
 		return false, err
 	}
 
-	if ok, err := v.Except.isValid(p); !ok {
-		return false, err
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ExceptWithinCIDR:
+
+			if ok, err := r.isExceptWithinCIDR(p.CIDR, p.Except, fldPath.Child("except")); !ok {
+				errs = append(errs, err)
+			}
+
+		case OverlappingExcepts:
+
+			if ok, err := r.isOverlappingExcepts(p.Except, fldPath.Child("except")); !ok {
+				errs = append(errs, err)
+			}
+
+		}
 	}
 
-	return true, nil
+	if ok, err := v.CIDR.isValid(p, fldPath.Child("cidr")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := v.Except.isValid(p, fldPath.Child("except")); !ok {
+		errs = append(errs, err)
+	}
+
+	return aggregate(errs)
+}
+
+// isValidIPBlockCIDRs reports the malformed cidr and except entries of
+// ipBlock p
+func isValidIPBlockCIDRs(p *networkingv1.IPBlock, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	if _, _, err := net.ParseCIDR(p.CIDR); err != nil {
+		errs = append(errs, invalidCIDR(fldPath.Child("cidr"), p.CIDR))
+	}
+
+	for i, e := range p.Except {
+		if _, _, err := net.ParseCIDR(e); err != nil {
+			errs = append(errs, invalidCIDR(fldPath.Child("except").Index(i), e))
+		}
+	}
+
+	return aggregate(errs)
 }
 
 // CIDR abstract CIDR object
@@ -230,7 +636,18 @@ type CIDR struct {
 
 // supported rules to check
 // MaskBitsSize
-func (c *CIDR) isValid(p *networkingv1.IPBlock) (bool, error) {
+// MaskBitsSizeV4
+// MaskBitsSizeV6
+// AddressFamily
+// WithinCIDRs
+// NotOverlappingCIDRs
+func (c *CIDR) supportedRules() []RuleName {
+	return []RuleName{MaskBitsSize, MaskBitsSizeV4, MaskBitsSizeV6, AddressFamily, WithinCIDRs, NotOverlappingCIDRs}
+}
+
+func (c *CIDR) isValid(p *networkingv1.IPBlock, fldPath *field.Path) (bool, error) {
+
+	var errs []error
 
 	for _, r := range c.Rules {
 
@@ -238,12 +655,44 @@ func (c *CIDR) isValid(p *networkingv1.IPBlock) (bool, error) {
 
 		case MaskBitsSize:
 
-			return r.isValidMask(p.CIDR)
+			if ok, err := r.isValidMask(p.CIDR, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case MaskBitsSizeV4:
+
+			if ok, err := r.isValidFamilyMask(p.CIDR, IPv4, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case MaskBitsSizeV6:
+
+			if ok, err := r.isValidFamilyMask(p.CIDR, IPv6, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case AddressFamily:
+
+			if ok, err := r.isValidAddressFamily(p.CIDR, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case WithinCIDRs:
+
+			if ok, err := r.isWithinCIDRs(p.CIDR, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case NotOverlappingCIDRs:
+
+			if ok, err := r.isNotOverlappingCIDRs(p.CIDR, p.Except, fldPath); !ok {
+				errs = append(errs, err)
+			}
 
 		}
 	}
 
-	return true, nil
+	return aggregate(errs)
 
 }
 
@@ -255,7 +704,16 @@ type Except struct {
 // supported rules to check
 // ListSize
 // MaskBitsSize
-func (v *Except) isValid(p *networkingv1.IPBlock) (bool, error) {
+// MaskBitsSizeV4
+// MaskBitsSizeV6
+// WithinCIDRs
+func (v *Except) supportedRules() []RuleName {
+	return []RuleName{ListSize, MaskBitsSize, MaskBitsSizeV4, MaskBitsSizeV6, WithinCIDRs}
+}
+
+func (v *Except) isValid(p *networkingv1.IPBlock, fldPath *field.Path) (bool, error) {
+
+	var errs []error
 
 	for _, r := range v.Rules {
 
@@ -263,16 +721,38 @@ func (v *Except) isValid(p *networkingv1.IPBlock) (bool, error) {
 
 		case ListSize:
 
-			return r.isValidListSize(len(p.Except))
+			if ok, err := r.isValidListSize(len(p.Except), fldPath); !ok {
+				errs = append(errs, err)
+			}
 
 		case MaskBitsSize:
 
-			return r.isValidMaskList(p.Except)
+			if ok, err := r.isValidMaskList(p.Except, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case MaskBitsSizeV4:
+
+			if ok, err := r.isValidFamilyMaskList(p.Except, IPv4, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case MaskBitsSizeV6:
+
+			if ok, err := r.isValidFamilyMaskList(p.Except, IPv6, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case WithinCIDRs:
+
+			if ok, err := r.isWithinCIDRsList(p.Except, fldPath); !ok {
+				errs = append(errs, err)
+			}
 
 		}
 	}
 
-	return true, nil
+	return aggregate(errs)
 }
 
 // NetworkPolicyPeer describes a peer to allow traffic from. Only certain combinations of
@@ -282,35 +762,39 @@ type NetworkPolicyPeer struct {
 	IPBlock           IPBlock           `json:"ipBlock,omitempty"`
 }
 
-func (v *NetworkPolicyPeer) isValid(p []networkingv1.NetworkPolicyPeer) (bool, error) {
+func (v *NetworkPolicyPeer) isValid(p []networkingv1.NetworkPolicyPeer, fldPath *field.Path) (bool, error) {
 
-	for _, i := range p {
+	var errs []error
 
-		switch {
+	for k, i := range p {
 
-		case i.PodSelector != nil:
+		if i.PodSelector != nil {
 
-			if ok, err := v.PodSelector.isValid(i.PodSelector); !ok {
-				return false, err
+			if ok, err := v.PodSelector.isValid(i.PodSelector, fldPath.Index(k).Child("podSelector")); !ok {
+				errs = append(errs, err)
 			}
 
-		case i.NamespaceSelector != nil:
+		}
+
+		if i.NamespaceSelector != nil {
 
-			if ok, err := v.NamespaceSelector.isValid(i.NamespaceSelector); !ok {
-				return false, err
+			if ok, err := v.NamespaceSelector.isValid(i.NamespaceSelector, fldPath.Index(k).Child("namespaceSelector")); !ok {
+				errs = append(errs, err)
 			}
 
-		case i.IPBlock != nil:
+		}
+
+		if i.IPBlock != nil {
 
-			if ok, err := v.IPBlock.isValid(i.IPBlock); !ok {
-				return false, err
+			if ok, err := v.IPBlock.isValid(i.IPBlock, fldPath.Index(k).Child("ipBlock")); !ok {
+				errs = append(errs, err)
 			}
 
 		}
 
 	}
 
-	return true, nil
+	return aggregate(errs)
 
 }
 
@@ -319,9 +803,9 @@ type PodSelector struct {
 	MatchLabels MatchLabels `json:"matchLabels"`
 }
 
-func (v *PodSelector) isValid(p *metav1.LabelSelector) (bool, error) {
+func (v *PodSelector) isValid(p *metav1.LabelSelector, fldPath *field.Path) (bool, error) {
 
-	return v.MatchLabels.isValid(p.MatchLabels)
+	return v.MatchLabels.isValid(p.MatchLabels, fldPath.Child("matchLabels"))
 
 }
 
@@ -330,9 +814,9 @@ type NamespaceSelector struct {
 	MatchLabels MatchLabels `json:"matchLabels"`
 }
 
-func (v *NamespaceSelector) isValid(p *metav1.LabelSelector) (bool, error) {
+func (v *NamespaceSelector) isValid(p *metav1.LabelSelector, fldPath *field.Path) (bool, error) {
 
-	return v.MatchLabels.isValid(p.MatchLabels)
+	return v.MatchLabels.isValid(p.MatchLabels, fldPath.Child("matchLabels"))
 
 }
 
@@ -343,18 +827,34 @@ type MatchLabels struct {
 
 // supported rules to check
 // LabelCount
-func (v *MatchLabels) isValid(p map[string]string) (bool, error) {
+// LabelValues
+func (v *MatchLabels) supportedRules() []RuleName {
+	return []RuleName{LabelCount, LabelValues}
+}
+
+func (v *MatchLabels) isValid(p map[string]string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
 
 	for _, r := range v.Rules {
 
 		switch r.Name {
 
 		case LabelCount:
-			return r.isValidListSize(len(p))
+
+			if ok, err := r.isValidLabelCount(p, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case LabelValues:
+
+			if ok, err := r.isValidLabelValues(p, fldPath); !ok {
+				errs = append(errs, err)
+			}
 
 		}
 	}
 
-	return true, nil
+	return aggregate(errs)
 
 }
@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/golang/glog"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
@@ -53,11 +57,14 @@ func TestAdmissionValidator(t *testing.T) {
 	}
 
 	for _, i := range tests {
-		v := NewAdmissionValidator(i.configfile)
+		v, err := NewAdmissionValidator(i.configfile)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
 		p := newNetworkPolicy(i.policyFile)
 		expected := i.expected
 
-		if result, _ := v.IsValid(p); result != expected {
+		if result, _ := v.IsValid(p, nil); result != expected {
 			t.Errorf("result was %v and expected is %v", result, expected)
 		}
 
@@ -65,6 +72,39 @@ func TestAdmissionValidator(t *testing.T) {
 
 }
 
+func TestAdmissionValidatorConfigErrors(t *testing.T) {
+
+	if v, err := NewAdmissionValidator("../../files/missing.yaml"); err == nil {
+		t.Errorf("expected an error for a missing config file, got validator %+v", v)
+	}
+
+	tests := []string{
+		"networkPolicyValidator: [",
+		"networkPolicyValidator: invalid",
+		"networkPolicyValidator:\n  allowedPolicyTypes: Ingress",
+	}
+
+	for _, i := range tests {
+
+		f, err := ioutil.TempFile("", "validator")
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		defer os.Remove(f.Name())
+
+		if _, err := f.WriteString(i); err != nil {
+			t.Fatalf("error %v", err)
+		}
+		f.Close()
+
+		if v, err := NewAdmissionValidator(f.Name()); err == nil {
+			t.Errorf("%q: expected an error, got validator %+v", i, v)
+		}
+
+	}
+
+}
+
 func TestCIDR(t *testing.T) {
 
 	tests := []struct {
@@ -116,6 +156,235 @@ func TestCIDR(t *testing.T) {
 			true,
 			`{ "cidr":"192.168.0.0/20"}`,
 		},
+		{
+			`{ "rules": [
+				{
+					"name": "WithinCIDRs",
+					"operator": "In",
+					"values": ["10.0.0.0/8"]
+				}
+			]}`,
+			true,
+			`{ "cidr":"10.1.0.0/16"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "WithinCIDRs",
+					"operator": "In",
+					"values": ["10.0.0.0/8", "172.16.0.0/12"]
+				}
+			]}`,
+			false,
+			`{ "cidr":"8.8.8.0/29"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "WithinCIDRs",
+					"operator": "NotIn",
+					"values": ["10.0.0.0/8"]
+				}
+			]}`,
+			true,
+			`{ "cidr":"8.8.8.0/29"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "WithinCIDRs",
+					"operator": "In",
+					"values": ["0.0.0.0/1"]
+				}
+			]}`,
+			false,
+			`{ "cidr":"0.0.0.0/0"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "NotOverlappingCIDRs",
+					"operator": "In",
+					"values": ["169.254.0.0/16"]
+				}
+			]}`,
+			false,
+			`{ "cidr":"0.0.0.0/0"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "NotOverlappingCIDRs",
+					"operator": "In",
+					"values": ["169.254.0.0/16"]
+				}
+			]}`,
+			true,
+			`{ "cidr":"0.0.0.0/0", "except":["169.254.0.0/16"]}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "NotOverlappingCIDRs",
+					"operator": "In",
+					"values": ["169.254.0.0/16"]
+				}
+			]}`,
+			false,
+			`{ "cidr":"0.0.0.0/0", "except":["169.254.169.254/32"]}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "NotOverlappingCIDRs",
+					"operator": "In",
+					"values": ["169.254.169.254/32", "10.0.0.0/8"]
+				}
+			]}`,
+			true,
+			`{ "cidr":"192.168.0.0/16"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "NotOverlappingCIDRs",
+					"operator": "In",
+					"values": ["10.0.0.0/8"]
+				}
+			]}`,
+			false,
+			`{ "cidr":"10.20.0.0/16"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSizeV4",
+					"operator": "Ge",
+					"value": 24
+				},
+				{
+					"name": "MaskBitsSizeV6",
+					"operator": "Ge",
+					"value": 64
+				}
+			]}`,
+			true,
+			`{ "cidr":"10.0.0.0/29"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSizeV4",
+					"operator": "Ge",
+					"value": 24
+				},
+				{
+					"name": "MaskBitsSizeV6",
+					"operator": "Ge",
+					"value": 64
+				}
+			]}`,
+			false,
+			`{ "cidr":"10.0.0.0/16"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSizeV4",
+					"operator": "Ge",
+					"value": 24
+				},
+				{
+					"name": "MaskBitsSizeV6",
+					"operator": "Ge",
+					"value": 64
+				}
+			]}`,
+			true,
+			`{ "cidr":"2001:db8::/64"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSizeV4",
+					"operator": "Ge",
+					"value": 24
+				},
+				{
+					"name": "MaskBitsSizeV6",
+					"operator": "Ge",
+					"value": 64
+				}
+			]}`,
+			false,
+			`{ "cidr":"2001:db8::/48"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSize",
+					"operator": "Ge",
+					"value": 24
+				}
+			]}`,
+			true,
+			`{ "cidr":"2001:db8::/48"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSizeV6",
+					"operator": "Ge",
+					"value": 64
+				}
+			]}`,
+			false,
+			`{ "cidr":"2001:db8::/48/64"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSize",
+					"operator": "Ge",
+					"value": 24
+				}
+			]}`,
+			false,
+			`{ "cidr":"not-a-cidr"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "AddressFamily",
+					"operator": "NotIn",
+					"values": ["IPv6"]
+				}
+			]}`,
+			true,
+			`{ "cidr":"10.0.0.0/8"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "AddressFamily",
+					"operator": "NotIn",
+					"values": ["IPv6"]
+				}
+			]}`,
+			false,
+			`{ "cidr":"::/0"}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "AddressFamily",
+					"operator": "In",
+					"values": ["IPv4"]
+				}
+			]}`,
+			false,
+			`{ "cidr":"::ffff:10.0.0.0/104"}`,
+		},
 	}
 
 	for _, i := range tests {
@@ -130,7 +399,7 @@ func TestCIDR(t *testing.T) {
 			t.Errorf("error %v", err)
 		}
 
-		result, _ := a.isValid(&b)
+		result, _ := a.isValid(&b, field.NewPath("cidr"))
 
 		if result != i.expected {
 			t.Errorf(" %v", result)
@@ -215,7 +484,7 @@ func TestAllowedPolicyType(t *testing.T) {
 			t.Errorf("error %v", err)
 		}
 
-		result, _ := a.isValid(&b.Spec)
+		result, _ := a.isValid(&b.Spec, field.NewPath("spec"))
 
 		if result != i.expected {
 			t.Errorf(" %v", result)
@@ -276,6 +545,60 @@ func TestExcept(t *testing.T) {
 			true,
 			`{ "except":["192.168.0.0/19","192.168.0.0/19"]}`,
 		},
+		{
+			`{ "rules": [
+				{
+					"name": "WithinCIDRs",
+					"operator": "In",
+					"values": ["192.168.0.0/16"]
+				}
+			]}`,
+			true,
+			`{ "except":["192.168.1.0/24","192.168.2.0/24"]}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "WithinCIDRs",
+					"operator": "In",
+					"values": ["192.168.0.0/16"]
+				}
+			]}`,
+			false,
+			`{ "except":["192.168.1.0/24","10.0.0.0/24"]}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSizeV4",
+					"operator": "Ge",
+					"value": 24
+				},
+				{
+					"name": "MaskBitsSizeV6",
+					"operator": "Ge",
+					"value": 64
+				}
+			]}`,
+			true,
+			`{ "except":["10.0.0.0/24","2001:db8::/64"]}`,
+		},
+		{
+			`{ "rules": [
+				{
+					"name": "MaskBitsSizeV4",
+					"operator": "Ge",
+					"value": 24
+				},
+				{
+					"name": "MaskBitsSizeV6",
+					"operator": "Ge",
+					"value": 64
+				}
+			]}`,
+			false,
+			`{ "except":["10.0.0.0/29","2001:db8::/56"]}`,
+		},
 	}
 
 	for _, i := range tests {
@@ -290,7 +613,7 @@ func TestExcept(t *testing.T) {
 			t.Errorf("error %v", err)
 		}
 
-		result, err := a.isValid(&b)
+		result, err := a.isValid(&b, field.NewPath("except"))
 
 		if result != i.expected {
 			t.Errorf(" %v", err)
@@ -300,58 +623,405 @@ func TestExcept(t *testing.T) {
 
 }
 
-func TestMatchLabels(t *testing.T) {
+func TestIPBlock(t *testing.T) {
+
+	rules := `{ "rules": [
+		{
+			"name": "ExceptWithinCIDR",
+			"operator": "In",
+			"values": ["true"]
+		},
+		{
+			"name": "OverlappingExcepts",
+			"operator": "In",
+			"values": ["false"]
+		}
+	]}`
 
 	tests := []struct {
-		rules       string
-		expected    bool
-		MatchLabels string
+		ipBlock  string
+		expected []string
 	}{
-		{`{ "rules": [
-			{
-				"name": "LabelCount",
-				"operator": "Equals",
-				"value": 1
-			}
-		]}`,
-			true,
-			`{
-				"matchLabels": {
-				"teste": "db"
-				}
-			}`,
+		{`{ "cidr":"10.0.0.0/16", "except":["10.0.1.0/24","10.0.2.0/24"]}`, nil},
+		{`{ "cidr":"10.0.0.0/16"}`, nil},
+		{`{ "cidr":"2001:db8::/32", "except":["2001:db8:1::/48"]}`, nil},
+		{
+			`{ "cidr":"10.0.0.0/16", "except":["10.0.1.0/24","10.1.0.0/24"]}`,
+			[]string{"ipBlock.except[1]: error InvalidExceptWithinCIDR: except entry 10.1.0.0/24 is not within cidr 10.0.0.0/16"},
 		},
-		{`{ "rules": [
-			{
-				"name": "LabelCount",
-				"operator": "Ge",
-				"value": 1
-			}
-		]}`,
-			true,
-			`{
-				"matchLabels": {
-				"teste": "db"
-				}
-			}`,
+		{
+			`{ "cidr":"10.0.0.0/16", "except":["10.0.0.0/16"]}`,
+			[]string{"ipBlock.except[0]: error InvalidExceptWithinCIDR: except entry 10.0.0.0/16 is not within cidr 10.0.0.0/16"},
 		},
-		{`{ "rules": [
-			{
-				"name": "LabelCount",
-				"operator": "Le",
-				"value": 1
-			}
-		]}`,
-			true,
-			`{
-				"matchLabels": {
-				"teste": "db"
-				}
-			}`,
+		{
+			`{ "cidr":"10.0.0.0/16", "except":["::/0"]}`,
+			[]string{"ipBlock.except[0]: error InvalidExceptWithinCIDR: except entry ::/0 is not within cidr 10.0.0.0/16"},
 		},
-		{`{ "rules": [
-			{
-				"name": "LabelCount",
+		{
+			`{ "cidr":"10.0.0.0/16", "except":["10.0.1.0/24","10.0.2.0/24","10.0.1.0/24"]}`,
+			[]string{"ipBlock.except[2]: error InvalidOverlappingExcepts: except entry 10.0.1.0/24 duplicates ipBlock.except[0]"},
+		},
+		{
+			`{ "cidr":"10.0.0.0/16", "except":["10.0.1.0/24","10.0.0.0/20"]}`,
+			[]string{"ipBlock.except[1]: error InvalidOverlappingExcepts: except entry 10.0.0.0/20 overlaps ipBlock.except[0] 10.0.1.0/24"},
+		},
+		{
+			`{ "cidr":"10.0.0.0/16", "except":["10.1.0.0/24","10.1.0.128/25"]}`,
+			[]string{
+				"ipBlock.except[0]: error InvalidExceptWithinCIDR: except entry 10.1.0.0/24 is not within cidr 10.0.0.0/16",
+				"ipBlock.except[1]: error InvalidExceptWithinCIDR: except entry 10.1.0.128/25 is not within cidr 10.0.0.0/16",
+				"ipBlock.except[1]: error InvalidOverlappingExcepts: except entry 10.1.0.128/25 overlaps ipBlock.except[0] 10.1.0.0/24",
+			},
+		},
+		{
+			`{ "cidr":"10.0.0.0/33", "except":["10.0.1.0/24","bogus"]}`,
+			[]string{
+				"ipBlock.cidr: error InvalidCIDR: must be a valid CIDR, e.g. 10.0.0.0/16",
+				"ipBlock.except[1]: error InvalidCIDR: must be a valid CIDR, e.g. 10.0.0.0/16",
+			},
+		},
+		{
+			`{ "cidr":"10.0.0.1"}`,
+			[]string{"ipBlock.cidr: error InvalidCIDR: must be a valid CIDR, e.g. 10.0.0.0/16"},
+		},
+	}
+
+	a := IPBlock{}
+	if err := json.Unmarshal([]byte(rules), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		b := networkingv1.IPBlock{}
+		if err := json.Unmarshal([]byte(i.ipBlock), &b); err != nil {
+			t.Errorf("%s: error %v", i.ipBlock, err)
+		}
+
+		result, err := a.isValid(&b, field.NewPath("ipBlock"))
+
+		if result != (len(i.expected) == 0) {
+			t.Errorf("%s: result was %v: %v", i.ipBlock, result, err)
+			continue
+		}
+
+		if err == nil {
+			continue
+		}
+
+		l, _ := err.(ViolationList)
+		if len(l) != len(i.expected) {
+			t.Errorf("%s: violations were %v and expected are %v", i.ipBlock, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if m := fmt.Sprintf("%s: %s", l[k].Field, l[k].Message); m != e {
+				t.Errorf("%s: violation was %q and expected is %q", i.ipBlock, m, e)
+			}
+		}
+
+	}
+
+}
+
+// TestIPBlockMalformed checks that malformed and edge case ipBlocks are
+// validated without panicking, the cases are followed by ipBlocks built from
+// random fragments of addresses with a fixed seed.
+func TestIPBlockMalformed(t *testing.T) {
+
+	rules := `{
+		"rules": [
+			{ "name": "ExceptWithinCIDR", "operator": "In", "values": ["true"] },
+			{ "name": "OverlappingExcepts", "operator": "In", "values": ["false"] }
+		],
+		"cidr": { "rules": [
+			{ "name": "MaskBitsSize", "operator": "Ge", "value": 8 },
+			{ "name": "MaskBitsSizeV4", "operator": "Ge", "value": 16 },
+			{ "name": "MaskBitsSizeV6", "operator": "Ge", "value": 48 },
+			{ "name": "AddressFamily", "operator": "In", "values": ["IPv4", "IPv6"] },
+			{ "name": "WithinCIDRs", "operator": "In", "values": ["10.0.0.0/8", "2001:db8::/32"] },
+			{ "name": "NotOverlappingCIDRs", "operator": "In", "values": ["169.254.0.0/16"] }
+		]},
+		"except": { "rules": [
+			{ "name": "ListSize", "operator": "Le", "value": 4 },
+			{ "name": "MaskBitsSize", "operator": "Ge", "value": 8 },
+			{ "name": "MaskBitsSizeV4", "operator": "Ge", "value": 16 },
+			{ "name": "MaskBitsSizeV6", "operator": "Ge", "value": 48 },
+			{ "name": "WithinCIDRs", "operator": "In", "values": ["10.0.0.0/8"] }
+		]}
+	}`
+
+	a := IPBlock{}
+	if err := json.Unmarshal([]byte(rules), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	tests := []networkingv1.IPBlock{
+		{CIDR: "10.0.0.0/16", Except: []string{"10.0.1.0/24", "10.0.2.0/24"}},
+		{},
+		{CIDR: "10.0.0.0/33", Except: []string{"::/0", "bogus"}},
+		{CIDR: "2001:db8::/32", Except: []string{"2001:db8::/48", "2001:db8::/32"}},
+		{CIDR: "::ffff:10.0.0.0/104", Except: []string{"10.0.0.0/8"}},
+		{CIDR: "0.0.0.0/0", Except: []string{"169.254.169.254/32", "", ""}},
+		{CIDR: "10.0.0.0", Except: []string{"10.0.0.0/-1"}},
+		{CIDR: "10.0.0.0/", Except: []string{"10.0.0.0/08"}},
+		{CIDR: "/8", Except: []string{"::/129"}},
+		{CIDR: "256.0.0.0/8", Except: []string{"10.0.0.0/8/8"}},
+		{CIDR: "10.0.0.0/8 ", Except: []string{" 10.0.0.0/8"}},
+		{CIDR: "fe80::1%eth0/64", Except: []string{"2001:db8::/48"}},
+	}
+
+	fragments := []string{"", "0", "8", "10", "32", "33", "128", "255", "256", "-1", ".", ":", "::", "/", "ffff", "2001:db8", "10.0.0.0", "::ffff:", " ", "%"}
+	r := rand.New(rand.NewSource(1))
+
+	random := func() string {
+		var b strings.Builder
+		for n := r.Intn(8); n > 0; n-- {
+			b.WriteString(fragments[r.Intn(len(fragments))])
+		}
+		return b.String()
+	}
+
+	for n := 0; n < 10000; n++ {
+
+		var except []string
+		for k := r.Intn(4); k > 0; k-- {
+			except = append(except, random())
+		}
+
+		tests = append(tests, networkingv1.IPBlock{CIDR: random(), Except: except})
+	}
+
+	for _, i := range tests {
+
+		cidr, except := i.CIDR, i.Except
+		result, err := a.isValid(&i, field.NewPath("ipBlock"))
+
+		if result != (err == nil) {
+			t.Fatalf("%q %q: result was %v with error %v", cidr, except, result, err)
+		}
+
+		l, _ := err.(ViolationList)
+		if err != nil && len(l) == 0 {
+			t.Fatalf("%q %q: error %v is not a violation list", cidr, except, err)
+		}
+
+		for _, v := range l {
+			if !strings.HasPrefix(v.Field, "ipBlock.") || v.Message == "" {
+				t.Errorf("%q %q: violation %v", cidr, except, v)
+			}
+		}
+
+		if _, _, err := net.ParseCIDR(cidr); err != nil && (len(l) == 0 || l[0].Field != "ipBlock.cidr" || l[0].Rule != InvalidCIDR) {
+			t.Errorf("%q %q: violations were %v and expected is %s on ipBlock.cidr", cidr, except, l, InvalidCIDR)
+		}
+
+	}
+
+}
+
+func TestNetworkPolicyPort(t *testing.T) {
+
+	tests := []struct {
+		rules    string
+		ports    string
+		expected []string
+	}{
+		{
+			`{ "rules": [ { "name": "PortNumber", "operator": "Ge", "value": 1024 } ]}`,
+			`[{ "port": 8080 }, { "port": 8000, "endPort": 8080 }, { "port": "http" }]`,
+			nil,
+		},
+		{
+			`{ "rules": [ { "name": "PortNumber", "operator": "Ge", "value": 1024 } ]}`,
+			`[{ "port": 80 }, { "protocol": "UDP" }]`,
+			[]string{"ports[0].port 80", "ports[1].port 1"},
+		},
+		{
+			`{ "rules": [ { "name": "PortNumber", "operator": "Le", "value": 8080 } ]}`,
+			`[{ "port": 8000, "endPort": 9000 }]`,
+			[]string{"ports[0].port 9000"},
+		},
+		{
+			`{ "rules": [ { "name": "PortNumber", "operator": "In", "values": ["53", "80", "443"] } ]}`,
+			`[{ "port": 443 }, { "port": 53, "protocol": "UDP" }, { "port": 8443 }, { "port": 80, "endPort": 443 }, {}]`,
+			[]string{"ports[2].port 8443", "ports[3].port 80-443", "ports[4].port 1-65535"},
+		},
+		{
+			`{ "rules": [ { "name": "PortNumber", "operator": "In", "values": ["8080", "8081"] } ]}`,
+			`[{ "port": 8080, "endPort": 8081 }]`,
+			nil,
+		},
+		{
+			`{ "rules": [ { "name": "PortNumber", "operator": "NotIn", "values": ["22", "3389"] } ]}`,
+			`[{ "port": 443 }, { "port": 20, "endPort": 30 }, { "port": "ssh" }]`,
+			[]string{"ports[1].port 20-30"},
+		},
+		{
+			`{ "rules": [ { "name": "Protocol", "operator": "In", "values": ["TCP", "UDP"] } ]}`,
+			`[{ "port": 443 }, { "port": 53, "protocol": "UDP" }, { "port": 3868, "protocol": "SCTP" }]`,
+			[]string{"ports[2].protocol SCTP"},
+		},
+		{
+			`{ "rules": [ { "name": "NamedPorts", "operator": "In", "values": ["false"] } ]}`,
+			`[{ "port": 443 }, { "port": "metrics" }, {}]`,
+			[]string{"ports[1].port true"},
+		},
+		{
+			`{ "rules": [ { "name": "PortName", "operator": "Matches", "value": "http|metrics-.*" } ]}`,
+			`[{ "port": "http" }, { "port": "metrics-app" }, { "port": "grpc" }, { "port": 9090 }]`,
+			[]string{"ports[2].port grpc"},
+		},
+		{
+			`{ "rules": [ { "name": "PortRangeSize", "operator": "Le", "value": 100 } ]}`,
+			`[{ "port": 8000, "endPort": 8099 }, { "port": 8000, "endPort": 8100 }, { "port": 53 }, { "protocol": "UDP" }]`,
+			[]string{"ports[1].endPort 101", "ports[3].port 65535"},
+		},
+		{
+			`{ "rules": [ { "name": "AllPorts", "operator": "In", "values": ["false"] } ]}`,
+			`[]`,
+			[]string{"ports true"},
+		},
+		{
+			`{ "rules": [ { "name": "AllPorts", "operator": "In", "values": ["false"] } ]}`,
+			`[{ "port": 443 }, { "protocol": "UDP" }]`,
+			[]string{"ports[1] true"},
+		},
+	}
+
+	for _, i := range tests {
+
+		a := NetworkPolicyPort{}
+		if err := json.Unmarshal([]byte(i.rules), &a); err != nil {
+			t.Fatalf("%s: error %v", i.rules, err)
+		}
+
+		var p []networkingv1.NetworkPolicyPort
+		if err := json.Unmarshal([]byte(i.ports), &p); err != nil {
+			t.Fatalf("%s: error %v", i.ports, err)
+		}
+
+		result, err := a.isValid(p, field.NewPath("ports"))
+
+		if result != (len(i.expected) == 0) {
+			t.Errorf("%s %s: result was %v: %v", i.rules, i.ports, result, err)
+			continue
+		}
+
+		l, _ := err.(ViolationList)
+		if len(l) != len(i.expected) {
+			t.Errorf("%s %s: violations were %v and expected are %v", i.rules, i.ports, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if v := l[k].Field + " " + l[k].Actual; v != e {
+				t.Errorf("%s %s: violation was %q and expected is %q", i.rules, i.ports, v, e)
+			}
+		}
+
+	}
+
+}
+
+func TestNetworkPolicyPortRange(t *testing.T) {
+
+	tests := []struct {
+		ports    string
+		expected []string
+	}{
+		{`[{ "port": 8000, "endPort": 8000 }, { "port": 8000, "endPort": 8080 }, { "port": "http", "endPort": 80 }, {}]`, nil},
+		{`[{ "port": 8080, "endPort": 8000 }, { "port": 53 }, { "port": 443, "endPort": 80 }]`, []string{"ports[0].endPort 8000", "ports[2].endPort 80"}},
+	}
+
+	// the range is checked before the rules, which are skipped
+	a := NetworkPolicyPort{}
+	if err := json.Unmarshal([]byte(`{ "rules": [ { "name": "PortRangeSize", "operator": "Le", "value": 10 } ]}`), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		var p []networkingv1.NetworkPolicyPort
+		if err := json.Unmarshal([]byte(i.ports), &p); err != nil {
+			t.Fatalf("%s: error %v", i.ports, err)
+		}
+
+		_, err := isValidPortRanges(p, field.NewPath("ports"))
+
+		l, _ := err.(ViolationList)
+		if len(l) != len(i.expected) {
+			t.Errorf("%s: violations were %v and expected are %v", i.ports, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if v := l[k].Field + " " + l[k].Actual; v != e || l[k].Rule != InvalidPortRange {
+				t.Errorf("%s: violation was %q %s and expected is %q %s", i.ports, v, l[k].Rule, e, InvalidPortRange)
+			}
+		}
+
+		if ok, err := a.isValid(p, field.NewPath("ports")); len(i.expected) > 0 && (ok || len(err.(ViolationList)) != len(i.expected)) {
+			t.Errorf("%s: violations were %v and expected are %v", i.ports, err, i.expected)
+		}
+
+	}
+
+}
+
+func TestMatchLabels(t *testing.T) {
+
+	tests := []struct {
+		rules       string
+		expected    bool
+		MatchLabels string
+	}{
+		{`{ "rules": [
+			{
+				"name": "LabelCount",
+				"operator": "Equals",
+				"value": 1
+			}
+		]}`,
+			true,
+			`{
+				"matchLabels": {
+				"teste": "db"
+				}
+			}`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelCount",
+				"operator": "Ge",
+				"value": 1
+			}
+		]}`,
+			true,
+			`{
+				"matchLabels": {
+				"teste": "db"
+				}
+			}`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelCount",
+				"operator": "Le",
+				"value": 1
+			}
+		]}`,
+			true,
+			`{
+				"matchLabels": {
+				"teste": "db"
+				}
+			}`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelCount",
 				"operator": "Lt",
 				"value": 1
 			}
@@ -415,7 +1085,7 @@ func TestMatchLabels(t *testing.T) {
 			t.Errorf("error %v", err)
 		}
 
-		result, _ := a.isValid(b.MatchLabels)
+		result, _ := a.isValid(b.MatchLabels, field.NewPath("matchLabels"))
 
 		if result != i.expected {
 			t.Errorf(" %v", result)
@@ -424,3 +1094,366 @@ func TestMatchLabels(t *testing.T) {
 	}
 
 }
+
+func TestLabelValues(t *testing.T) {
+
+	tests := []struct {
+		rules       string
+		expected    bool
+		MatchLabels string
+	}{
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "Exists",
+				"key": "app"
+			}
+		]}`,
+			true,
+			`{ "matchLabels": { "app": "db" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "Exists",
+				"key": "app"
+			}
+		]}`,
+			false,
+			`{ "matchLabels": { "role": "db" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "DoesNotExist",
+				"key": "tier"
+			}
+		]}`,
+			false,
+			`{ "matchLabels": { "app": "db", "tier": "system" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "In",
+				"key": "app",
+				"values": ["db", "web"]
+			}
+		]}`,
+			true,
+			`{ "matchLabels": { "app": "web" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "In",
+				"key": "app",
+				"values": ["db", "web"]
+			}
+		]}`,
+			false,
+			`{ "matchLabels": { "role": "web" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "NotIn",
+				"key": "tier",
+				"values": ["system"]
+			}
+		]}`,
+			false,
+			`{ "matchLabels": { "app": "db", "tier": "system" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "NotIn",
+				"key": "tier",
+				"values": ["system"]
+			}
+		]}`,
+			true,
+			`{ "matchLabels": { "app": "db" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "Matches",
+				"key": "app",
+				"value": "team-[a-z]+"
+			}
+		]}`,
+			true,
+			`{ "matchLabels": { "app": "team-payments" } }`,
+		},
+		{`{ "rules": [
+			{
+				"name": "LabelValues",
+				"operator": "Matches",
+				"key": "app",
+				"value": "team-[a-z]+"
+			}
+		]}`,
+			false,
+			`{ "matchLabels": { "app": "payments-team-a" } }`,
+		},
+	}
+
+	for _, i := range tests {
+		a := MatchLabels{}
+		b := metav1.LabelSelector{}
+
+		if err := json.Unmarshal([]byte(i.rules), &a); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(i.MatchLabels), &b); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		result, err := a.isValid(b.MatchLabels, field.NewPath("matchLabels"))
+
+		if result != i.expected {
+			t.Errorf("result was %v and expected is %v: %v", result, i.expected, err)
+		}
+
+	}
+
+}
+
+func TestMultipleRules(t *testing.T) {
+
+	except := `{ "rules": [
+		{
+			"name": "MaskBitsSize",
+			"operator": "Ge",
+			"value": 29
+		},
+		{
+			"name": "ListSize",
+			"operator": "Le",
+			"value": 1
+		}
+	]}`
+
+	cidr := `{ "rules": [
+		{
+			"name": "MaskBitsSize",
+			"operator": "Ge",
+			"value": 24
+		},
+		{
+			"name": "MaskBitsSize",
+			"operator": "Le",
+			"value": 28
+		}
+	]}`
+
+	tests := []struct {
+		name     string
+		rules    string
+		ipBlock  string
+		validate func(rules []byte, b *networkingv1.IPBlock) (bool, error)
+		expected bool
+		errors   int
+	}{
+		{"except all rules pass", except, `{ "except":["192.168.1.0/29"]}`, validateExcept, true, 0},
+		{"except second rule fails", except, `{ "except":["192.168.1.0/29","192.168.2.0/29"]}`, validateExcept, false, 1},
+		{"except both rules fail", except, `{ "except":["192.168.1.0/24","192.168.2.0/29"]}`, validateExcept, false, 2},
+		{"cidr all rules pass", cidr, `{ "cidr":"192.168.0.0/26"}`, validateCIDR, true, 0},
+		{"cidr second rule fails", cidr, `{ "cidr":"192.168.0.0/30"}`, validateCIDR, false, 1},
+	}
+
+	for _, i := range tests {
+		b := networkingv1.IPBlock{}
+
+		if err := json.Unmarshal([]byte(i.ipBlock), &b); err != nil {
+			t.Errorf("%s: error %v", i.name, err)
+		}
+
+		result, err := i.validate([]byte(i.rules), &b)
+
+		if result != i.expected {
+			t.Errorf("%s: result was %v and expected is %v", i.name, result, i.expected)
+		}
+
+		if n := countErrors(err); n != i.errors {
+			t.Errorf("%s: %d errors reported and expected is %d: %v", i.name, n, i.errors, err)
+		}
+
+	}
+
+}
+
+func TestMultipleLabelRules(t *testing.T) {
+
+	rules := `{ "rules": [
+		{
+			"name": "LabelCount",
+			"operator": "Ge",
+			"value": 1
+		},
+		{
+			"name": "LabelValues",
+			"operator": "Exists",
+			"key": "app"
+		},
+		{
+			"name": "LabelValues",
+			"operator": "NotIn",
+			"key": "tier",
+			"values": ["system"]
+		}
+	]}`
+
+	tests := []struct {
+		MatchLabels string
+		expected    bool
+		errors      int
+	}{
+		{`{ "matchLabels": { "app": "db" } }`, true, 0},
+		{`{ "matchLabels": { "role": "db" } }`, false, 1},
+		{`{ "matchLabels": { "role": "db", "tier": "system" } }`, false, 2},
+		{`{ "matchLabels": {} }`, false, 2},
+	}
+
+	for _, i := range tests {
+		a := MatchLabels{}
+		b := metav1.LabelSelector{}
+
+		if err := json.Unmarshal([]byte(rules), &a); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(i.MatchLabels), &b); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		result, err := a.isValid(b.MatchLabels, field.NewPath("matchLabels"))
+
+		if result != i.expected {
+			t.Errorf("%s: result was %v and expected is %v", i.MatchLabels, result, i.expected)
+		}
+
+		if n := countErrors(err); n != i.errors {
+			t.Errorf("%s: %d errors reported and expected is %d: %v", i.MatchLabels, n, i.errors, err)
+		}
+
+	}
+
+}
+
+func validateExcept(rules []byte, b *networkingv1.IPBlock) (bool, error) {
+
+	a := Except{}
+	if err := json.Unmarshal(rules, &a); err != nil {
+		return false, err
+	}
+
+	return a.isValid(b, field.NewPath("except"))
+}
+
+func validateCIDR(rules []byte, b *networkingv1.IPBlock) (bool, error) {
+
+	a := CIDR{}
+	if err := json.Unmarshal(rules, &a); err != nil {
+		return false, err
+	}
+
+	return a.isValid(b, field.NewPath("cidr"))
+}
+
+func countErrors(err error) int {
+
+	if err == nil {
+		return 0
+	}
+
+	if l, ok := err.(ViolationList); ok {
+		return len(l)
+	}
+
+	return 1
+}
+
+func TestViolations(t *testing.T) {
+
+	rules := `{
+		"allowedPolicyTypes": ["Ingress"],
+		"ingress": {
+			"from": {
+				"ipBlock": {
+					"cidr": { "rules": [
+						{ "name": "MaskBitsSize", "operator": "Ge", "value": 24 }
+					]},
+					"except": { "rules": [
+						{ "name": "MaskBitsSize", "operator": "Ge", "value": 29 }
+					]}
+				}
+			},
+			"ports": { "rules": [
+				{ "name": "PortNumber", "operator": "Ge", "value": 1024 }
+			]}
+		}
+	}`
+
+	policy := `{
+		"policyTypes": ["Ingress", "Egress"],
+		"ingress": [
+			{
+				"from": [ { "ipBlock": { "cidr": "10.0.0.0/24" } } ],
+				"ports": [ { "port": 8080 } ]
+			},
+			{
+				"from": [
+					{ "ipBlock": {
+						"cidr": "10.0.0.0/16",
+						"except": ["10.0.1.0/29", "10.0.2.0/29", "10.0.3.0/24"]
+					} }
+				],
+				"ports": [ { "port": 8080 }, { "port": 80 } ]
+			}
+		]
+	}`
+
+	expected := []Violation{
+		{Field: "spec.policyTypes[1]", Rule: "AllowedPolicyTypes", Operator: OpIn, Expected: "[Ingress]", Actual: "Egress"},
+		{Field: "spec.ingress[1].from[0].ipBlock.cidr", Rule: MaskBitsSize, Operator: OpGe, Expected: "24", Actual: "16"},
+		{Field: "spec.ingress[1].from[0].ipBlock.except[2]", Rule: MaskBitsSize, Operator: OpGe, Expected: "29", Actual: "24"},
+		{Field: "spec.ingress[1].ports[1].port", Rule: PortNumber, Operator: OpGe, Expected: "1024", Actual: "80"},
+	}
+
+	a := NetworkPolicyValidator{}
+	b := networkingv1.NetworkPolicySpec{}
+
+	if err := json.Unmarshal([]byte(rules), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(policy), &b); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	result, err := a.isValid(&b, field.NewPath("spec"))
+	if result {
+		t.Fatalf("result was %v and expected is false", result)
+	}
+
+	l, ok := err.(ViolationList)
+	if !ok {
+		t.Fatalf("expected a ViolationList, got %T", err)
+	}
+
+	if len(l) != len(expected) {
+		t.Fatalf("%d violations reported and expected is %d: %v", len(l), len(expected), l)
+	}
+
+	for k, i := range expected {
+		v := l[k]
+		if v.Field != i.Field || v.Rule != i.Rule || v.Operator != i.Operator || v.Expected != i.Expected || v.Actual != i.Actual {
+			t.Errorf("violation was %+v and expected is %+v", *v, i)
+		}
+	}
+
+}
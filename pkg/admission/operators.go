@@ -1,6 +1,13 @@
 package admission
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
 
 // Operator is validation type abstraction
 type Operator string
@@ -11,6 +18,7 @@ const (
 	OpNotIn        Operator = "NotIn"
 	OpExists       Operator = "Exists"
 	OpDoesNotExist Operator = "DoesNotExist"
+	OpMatches      Operator = "Matches"
 	OpEq           Operator = "Equals"
 	OpGt           Operator = "Gt"
 	OpLt           Operator = "Lt"
@@ -18,87 +26,387 @@ const (
 	OpLe           Operator = "Le"
 )
 
+var allOperators = []Operator{
+	OpIn, OpNotIn, OpExists, OpDoesNotExist, OpMatches, OpEq, OpGt, OpLt, OpGe, OpLe,
+}
+
+// numericOperators compare the received value with the rule value as numbers
+var numericOperators = []Operator{OpEq, OpGt, OpLt, OpGe, OpLe}
+
+// operatorExec applies the operator o to the received value x and the
+// configured value y.
+//
+// Set operators (In, NotIn) expect x to be a scalar or a []string and y to be
+// a []string. Key operators (Exists, DoesNotExist) expect x to be a
+// map[string]string or a []string and y to be the key. Matches expects x to
+// be a scalar or a []string and y to be a regular expression. Every other
+// operator compares x and y as numbers, or as quantities when x is a
+// resource.Quantity.
 func operatorExec(x, y interface{}, o Operator) (bool, error) {
 
 	switch o {
 
 	case OpIn:
 
-		fmt.Println("a")
+		return opInExec(x, y)
 
 	case OpNotIn:
 
-		fmt.Println("b")
+		return opNotInExec(x, y)
 
 	case OpExists:
 
-		fmt.Println("c")
+		return opExistsExec(x, y)
 
 	case OpDoesNotExist:
 
-		fmt.Println("d")
+		return opDoesNotExistExec(x, y)
+
+	case OpMatches:
+
+		return opMatchesExec(x, y)
 
 	case OpGe:
 
-		return opGeExec(x.(int), y.(int)), nil
+		return opGeExec(x, y)
 
 	case OpGt:
 
-		return opGtExec(x.(int), y.(int))
+		return opGtExec(x, y)
 
 	case OpLt:
 
-		return opLtExec(x.(int), y.(int))
+		return opLtExec(x, y)
 
 	case OpLe:
 
-		return opLeExec(x.(int), y.(int)), nil
+		return opLeExec(x, y)
 
 	case OpEq:
 
-		return opEqExec(x.(int), y.(int)), nil
+		return opEqExec(x, y)
 
 	}
 
-	return false, nil
+	return false, fmt.Errorf("error UnknownOperator: operator %q is not supported", o)
 }
 
-func opEqExec(x, y int) bool {
+func opEqExec(x, y interface{}) (bool, error) {
 
-	if x == y {
-		return true
+	c, err := compare(x, y)
+	if err != nil {
+		return false, err
 	}
-	return false
+	return c == 0, nil
 }
 
-func opGtExec(x, y int) (bool, error) {
+func opGtExec(x, y interface{}) (bool, error) {
 
-	if x > y {
-		return true, nil
+	c, err := compare(x, y)
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return c > 0, nil
 }
 
-func opLtExec(x, y int) (bool, error) {
+func opLtExec(x, y interface{}) (bool, error) {
 
-	if x < y {
-		return true, nil
+	c, err := compare(x, y)
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return c < 0, nil
 }
 
-func opGeExec(x, y int) bool {
+func opGeExec(x, y interface{}) (bool, error) {
 
-	if x >= y {
-		return true
+	c, err := compare(x, y)
+	if err != nil {
+		return false, err
 	}
-	return false
+	return c >= 0, nil
+}
+
+func opLeExec(x, y interface{}) (bool, error) {
+
+	c, err := compare(x, y)
+	if err != nil {
+		return false, err
+	}
+	return c <= 0, nil
+}
+
+// opInExec returns true when x, or every element of x when it is a list,
+// belongs to the set y.
+func opInExec(x, y interface{}) (bool, error) {
+
+	set, err := toStringList(y)
+	if err != nil {
+		return false, err
+	}
+
+	values, err := toStringList(x)
+	if err != nil {
+		return false, err
+	}
+
+	for _, i := range values {
+		if !contains(set, i) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// opNotInExec returns true when neither x nor any element of x belongs to the
+// set y.
+func opNotInExec(x, y interface{}) (bool, error) {
+
+	set, err := toStringList(y)
+	if err != nil {
+		return false, err
+	}
+
+	values, err := toStringList(x)
+	if err != nil {
+		return false, err
+	}
+
+	for _, i := range values {
+		if contains(set, i) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// opExistsExec returns true when the key y is present in x.
+func opExistsExec(x, y interface{}) (bool, error) {
+
+	key, err := toString(y)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := x.(type) {
+
+	case map[string]string:
+
+		_, ok := v[key]
+		return ok, nil
+
+	case []string:
+
+		return contains(v, key), nil
+
+	}
+
+	return false, fmt.Errorf("error InvalidOperand: %T does not have keys", x)
+}
+
+// opDoesNotExistExec returns true when the key y is absent from x.
+func opDoesNotExistExec(x, y interface{}) (bool, error) {
+
+	ok, err := opExistsExec(x, y)
+	if err != nil {
+		return false, err
+	}
+	return !ok, nil
+}
+
+// compilePattern compiles pattern to match whole values
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// opMatchesExec returns true when x, or every element of x when it is a list,
+// fully matches the regular expression y. y is either a pattern compiled
+// with compilePattern or the pattern itself.
+func opMatchesExec(x, y interface{}) (bool, error) {
+
+	re, ok := y.(*regexp.Regexp)
+	if !ok {
+
+		pattern, err := toString(y)
+		if err != nil {
+			return false, err
+		}
+
+		re, err = compilePattern(pattern)
+		if err != nil {
+			return false, fmt.Errorf("error InvalidOperand: %q is not a valid pattern: %v", pattern, err)
+		}
+
+	}
+
+	values, err := toStringList(x)
+	if err != nil {
+		return false, err
+	}
+
+	for _, i := range values {
+		if !re.MatchString(i) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// compare returns -1, 0 or 1 when x is respectively lower than, equal to or
+// greater than y.
+func compare(x, y interface{}) (int, error) {
+
+	switch x.(type) {
+
+	case resource.Quantity, *resource.Quantity:
+
+		a, err := toQuantity(x)
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := toQuantity(y)
+		if err != nil {
+			return 0, err
+		}
+
+		return a.Cmp(b), nil
+
+	}
+
+	a, err := toNumber(x)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := toNumber(y)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case a < b:
+		return -1, nil
+	case a > b:
+		return 1, nil
+	}
+
+	return 0, nil
 }
 
-func opLeExec(x, y int) bool {
+func toNumber(x interface{}) (int64, error) {
+
+	switch v := x.(type) {
+
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case intstr.IntOrString:
+		if v.Type == intstr.Int {
+			return int64(v.IntVal), nil
+		}
+		return toNumber(v.StrVal)
+	case *intstr.IntOrString:
+		if v == nil {
+			break
+		}
+		return toNumber(*v)
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error InvalidOperand: %q is not a number", v)
+		}
+		return n, nil
 
-	if x <= y {
-		return true
 	}
+
+	return 0, fmt.Errorf("error InvalidOperand: %T is not a number", x)
+}
+
+func toQuantity(x interface{}) (resource.Quantity, error) {
+
+	switch v := x.(type) {
+
+	case resource.Quantity:
+		return v, nil
+	case *resource.Quantity:
+		if v == nil {
+			break
+		}
+		return *v, nil
+	case int:
+		return *resource.NewQuantity(int64(v), resource.DecimalSI), nil
+	case int64:
+		return *resource.NewQuantity(v, resource.DecimalSI), nil
+	case intstr.IntOrString:
+		if v.Type == intstr.Int {
+			return toQuantity(int(v.IntVal))
+		}
+		return toQuantity(v.StrVal)
+	case string:
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return resource.Quantity{}, fmt.Errorf("error InvalidOperand: %q is not a quantity", v)
+		}
+		return q, nil
+
+	}
+
+	return resource.Quantity{}, fmt.Errorf("error InvalidOperand: %T is not a quantity", x)
+}
+
+func toString(x interface{}) (string, error) {
+
+	switch v := x.(type) {
+
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case intstr.IntOrString:
+		return v.String(), nil
+
+	}
+
+	return "", fmt.Errorf("error InvalidOperand: %T is not a string", x)
+}
+
+func toStringList(x interface{}) ([]string, error) {
+
+	if l, ok := x.([]string); ok {
+		return l, nil
+	}
+
+	s, err := toString(x)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{s}, nil
+}
+
+func contains(l []string, s string) bool {
+
+	for _, i := range l {
+		if i == s {
+			return true
+		}
+	}
+
 	return false
 }
@@ -1,6 +1,12 @@
 package admission
 
-import "testing"
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
 
 func TestNumberOperators(t *testing.T) {
 
@@ -53,3 +59,142 @@ func TestNumberOperators(t *testing.T) {
 	}
 
 }
+
+func TestSetOperators(t *testing.T) {
+
+	tests := []struct {
+		x        interface{}
+		op       Operator
+		y        interface{}
+		expected bool
+	}{
+		{"db", OpIn, []string{"db", "web"}, true},
+		{"cache", OpIn, []string{"db", "web"}, false},
+		{[]string{"db", "web"}, OpIn, []string{"db", "web", "cache"}, true},
+		{[]string{"db", "api"}, OpIn, []string{"db", "web"}, false},
+		{"cache", OpNotIn, []string{"db", "web"}, true},
+		{"db", OpNotIn, []string{"db", "web"}, false},
+		{[]string{"api", "cache"}, OpNotIn, []string{"db", "web"}, true},
+		{[]string{"api", "db"}, OpNotIn, []string{"db", "web"}, false},
+		{6379, OpIn, []string{"6379", "5432"}, true},
+		{map[string]string{"app": "db"}, OpExists, "app", true},
+		{map[string]string{"app": "db"}, OpExists, "tier", false},
+		{map[string]string{"app": "db"}, OpDoesNotExist, "tier", true},
+		{map[string]string{"app": "db"}, OpDoesNotExist, "app", false},
+		{[]string{"app", "tier"}, OpExists, "tier", true},
+	}
+
+	for _, i := range tests {
+
+		result, err := operatorExec(i.x, i.y, i.op)
+		if err != nil {
+			t.Errorf("%v %s %v: unexpected error %v", i.x, i.op, i.y, err)
+		}
+
+		if result != i.expected {
+			t.Errorf("%v %s %v: result was %v and expected is %v", i.x, i.op, i.y, result, i.expected)
+		}
+
+	}
+
+}
+
+func TestInvalidOperands(t *testing.T) {
+
+	tests := []struct {
+		x  interface{}
+		op Operator
+		y  interface{}
+	}{
+		{1, OpGe, "abc"},
+		{"abc", OpLt, 1},
+		{[]string{"a"}, OpEq, 1},
+		{"app", OpExists, "app"},
+		{1, OpIn, 1.5},
+		{1, Operator("Between"), 2},
+	}
+
+	for _, i := range tests {
+
+		result, err := operatorExec(i.x, i.y, i.op)
+		if err == nil {
+			t.Errorf("%v %s %v: expected an error", i.x, i.op, i.y)
+		}
+
+		if result {
+			t.Errorf("%v %s %v: result was %v and expected is false", i.x, i.op, i.y, result)
+		}
+
+	}
+
+}
+
+func TestQuantityOperators(t *testing.T) {
+
+	tests := []struct {
+		x        string
+		op       Operator
+		y        interface{}
+		expected bool
+	}{
+		{"500m", OpLe, "1", true},
+		{"1500m", OpLe, "1", false},
+		{"1Gi", OpGe, "512Mi", true},
+		{"1G", OpGe, "1Gi", false},
+		{"2", OpEq, 2, true},
+		{"100Mi", OpLt, "0.1Gi", true},
+	}
+
+	for _, i := range tests {
+
+		q := resource.MustParse(i.x)
+		result, err := operatorExec(&q, i.y, i.op)
+		if err != nil {
+			t.Errorf("%s %s %v: error %v", i.x, i.op, i.y, err)
+		}
+
+		if result != i.expected {
+			t.Errorf("%s %s %v: result was %v and expected is %v", i.x, i.op, i.y, result, i.expected)
+		}
+
+	}
+
+	if _, err := operatorExec(resource.MustParse("1"), "one", OpGe); err == nil {
+		t.Errorf("expected an error comparing a quantity with %q", "one")
+	}
+
+}
+
+func TestMatchesPattern(t *testing.T) {
+
+	tests := []struct {
+		rule     string
+		value    string
+		compiled bool
+		expected bool
+	}{
+		{`{"name": "ImageTag", "operator": "Matches", "value": "v[0-9]+\\.[0-9]+"}`, "v1.2", true, true},
+		{`{"name": "ImageTag", "operator": "Matches", "value": "v[0-9]+\\.[0-9]+"}`, "v1.2-rc", true, false},
+		{`{"name": "ImageTag", "operator": "Matches", "value": 8080}`, "8080", true, true},
+		{`{"name": "ImageTag", "operator": "In", "values": ["latest"]}`, "latest", false, true},
+		{`{"name": "ImageTag", "operator": "Matches", "value": "v[0-9"}`, "v1", false, false},
+	}
+
+	for _, i := range tests {
+
+		r := Rule{}
+		if err := json.Unmarshal([]byte(i.rule), &r); err != nil {
+			t.Fatalf("%s: error %v", i.rule, err)
+		}
+
+		if _, ok := r.operand().(*regexp.Regexp); ok != i.compiled {
+			t.Errorf("%s: operand was %v and expected compiled pattern is %v", i.rule, r.operand(), i.compiled)
+		}
+
+		if result, _ := operatorExec(i.value, r.operand(), r.Operator); result != i.expected {
+			t.Errorf("%s %s: result was %v and expected is %v", i.rule, i.value, result, i.expected)
+		}
+
+	}
+
+}
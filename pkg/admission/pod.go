@@ -0,0 +1,333 @@
+package admission
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidatePod checks the host access, containers and volumes of pod p with
+// the pod and resources rules of the profile of its namespace.
+func (v *NetworkAdmissionValidator) ValidatePod(p *corev1.Pod, namespaceLabels map[string]string) ViolationList {
+
+	validators := v.validators(p.Namespace, namespaceLabels)
+
+	var errs []error
+
+	if ok, err := validators.PodValidator.isValid(&p.Spec, field.NewPath("spec")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := validators.ResourcesValidator.isValid(&p.Spec, field.NewPath("spec")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := aggregate(errs); !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement)
+	}
+
+	return nil
+
+}
+
+// PodValidator provides the specification of a Pod
+type PodValidator struct {
+	Rules      []Rule             `json:"rules,omitempty"`
+	Containers ContainerValidator `json:"containers,omitempty"`
+	Volumes    Volumes            `json:"volumes,omitempty"`
+}
+
+// supported rules to check
+// HostNetwork
+// HostPID
+func (v *PodValidator) supportedRules() []RuleName {
+	return []RuleName{HostNetwork, HostPID}
+}
+
+func (v *PodValidator) isValid(p *corev1.PodSpec, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case HostNetwork:
+
+			if ok, err := r.check(strconv.FormatBool(p.HostNetwork), fldPath.Child("hostNetwork"), "InvalidHostNetwork", "hostNetwork"); !ok {
+				errs = append(errs, err)
+			}
+
+		case HostPID:
+
+			if ok, err := r.check(strconv.FormatBool(p.HostPID), fldPath.Child("hostPID"), "InvalidHostPID", "hostPID"); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	if ok, err := v.Containers.isValid(p.InitContainers, p.SecurityContext, fldPath.Child("initContainers")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := v.Containers.isValid(p.Containers, p.SecurityContext, fldPath.Child("containers")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := v.Containers.isValid(ephemeralContainers(p.EphemeralContainers), p.SecurityContext, fldPath.Child("ephemeralContainers")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := v.Volumes.isValid(p.Volumes, fldPath.Child("volumes")); !ok {
+		errs = append(errs, err)
+	}
+
+	return aggregate(errs)
+
+}
+
+// ContainerValidator provides the specification of the containers, init
+// containers and ephemeral containers of a Pod
+type ContainerValidator struct {
+	Image           Image           `json:"image,omitempty"`
+	SecurityContext SecurityContext `json:"securityContext,omitempty"`
+}
+
+func (v *ContainerValidator) isValid(p []corev1.Container, s *corev1.PodSecurityContext, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, c := range p {
+
+		if ok, err := v.Image.isValid(c.Image, fldPath.Index(k).Child("image")); !ok {
+			errs = append(errs, err)
+		}
+
+		if ok, err := v.SecurityContext.isValid(c.SecurityContext, s, fldPath.Index(k).Child("securityContext")); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+// ephemeralContainers returns the containers of the ephemeral containers p,
+// EphemeralContainerCommon has the same fields as Container.
+func ephemeralContainers(p []corev1.EphemeralContainer) []corev1.Container {
+
+	var c []corev1.Container
+	for _, i := range p {
+		c = append(c, corev1.Container(i.EphemeralContainerCommon))
+	}
+
+	return c
+}
+
+// Image checks the registry and the tag of container images. Images without
+// a registry are pulled from docker.io and images without a tag nor a digest
+// have the latest tag.
+type Image struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// ImageRegistry
+// ImageTag
+func (v *Image) supportedRules() []RuleName {
+	return []RuleName{ImageRegistry, ImageTag}
+}
+
+func (v *Image) isValid(image string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+	registry, tag := parseImage(image)
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ImageRegistry:
+
+			if ok, err := r.check(registry, fldPath, "InvalidImageRegistry", "image registry"); !ok {
+				errs = append(errs, err)
+			}
+
+		case ImageTag:
+
+			if ok, err := r.check(tag, fldPath, "InvalidImageTag", "image tag"); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// parseImage returns the registry and the tag of image. The tag is empty
+// when the image is only referenced by digest.
+func parseImage(image string) (string, string) {
+
+	name, digest := image, false
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], true
+	}
+
+	registry := "docker.io"
+	if i := strings.Index(name, "/"); i >= 0 {
+		if h := name[:i]; strings.ContainsAny(h, ".:") || h == "localhost" {
+			registry, name = h, name[i+1:]
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return registry, name[i+1:]
+	}
+
+	if digest {
+		return registry, ""
+	}
+
+	return registry, "latest"
+}
+
+// SecurityContext checks the security context of containers. Settings
+// missing from a container are taken from the pod security context.
+type SecurityContext struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// Privileged
+// RunAsNonRoot
+// AddedCapabilities
+func (v *SecurityContext) supportedRules() []RuleName {
+	return []RuleName{Privileged, RunAsNonRoot, AddedCapabilities}
+}
+
+func (v *SecurityContext) isValid(c *corev1.SecurityContext, s *corev1.PodSecurityContext, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	privileged, runAsNonRoot := false, false
+	var capabilities []corev1.Capability
+
+	if s != nil && s.RunAsNonRoot != nil {
+		runAsNonRoot = *s.RunAsNonRoot
+	}
+
+	if c != nil {
+
+		if c.Privileged != nil {
+			privileged = *c.Privileged
+		}
+
+		if c.RunAsNonRoot != nil {
+			runAsNonRoot = *c.RunAsNonRoot
+		}
+
+		if c.Capabilities != nil {
+			capabilities = c.Capabilities.Add
+		}
+
+	}
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case Privileged:
+
+			if ok, err := r.check(strconv.FormatBool(privileged), fldPath.Child("privileged"), "InvalidPrivileged", "privileged"); !ok {
+				errs = append(errs, err)
+			}
+
+		case RunAsNonRoot:
+
+			if ok, err := r.check(strconv.FormatBool(runAsNonRoot), fldPath.Child("runAsNonRoot"), "InvalidRunAsNonRoot", "runAsNonRoot"); !ok {
+				errs = append(errs, err)
+			}
+
+		case AddedCapabilities:
+
+			for k, i := range capabilities {
+				if ok, err := r.check(string(i), fldPath.Child("capabilities", "add").Index(k), "InvalidCapability", "added capability"); !ok {
+					errs = append(errs, err)
+				}
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// Volumes checks the volumes of a Pod
+type Volumes struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// VolumeTypes
+// HostPath
+func (v *Volumes) supportedRules() []RuleName {
+	return []RuleName{VolumeTypes, HostPath}
+}
+
+func (v *Volumes) isValid(p []corev1.Volume, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		for k, i := range p {
+
+			switch r.Name {
+
+			case VolumeTypes:
+
+				if ok, err := r.check(volumeType(&i.VolumeSource), fldPath.Index(k), "InvalidVolumeType", "volume type"); !ok {
+					errs = append(errs, err)
+				}
+
+			case HostPath:
+
+				if i.HostPath == nil {
+					continue
+				}
+
+				if ok, err := r.check(i.HostPath.Path, fldPath.Index(k).Child("hostPath", "path"), "InvalidHostPath", "hostPath path"); !ok {
+					errs = append(errs, err)
+				}
+
+			}
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// volumeType returns the json name of the volume source set in s, e.g.
+// hostPath or emptyDir.
+func volumeType(s *corev1.VolumeSource) string {
+
+	v := reflect.ValueOf(s).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Kind() == reflect.Ptr && !f.IsNil() {
+			return strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,310 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestParseImage(t *testing.T) {
+
+	tests := []struct {
+		image    string
+		registry string
+		tag      string
+	}{
+		{"nginx", "docker.io", "latest"},
+		{"nginx:1.15", "docker.io", "1.15"},
+		{"library/nginx:latest", "docker.io", "latest"},
+		{"gcr.io/project/app:v1", "gcr.io", "v1"},
+		{"registry.example.com:5000/app", "registry.example.com:5000", "latest"},
+		{"localhost/app:dev", "localhost", "dev"},
+		{"gcr.io/project/app@sha256:0123456789abcdef", "gcr.io", ""},
+		{"gcr.io/project/app:v1@sha256:0123456789abcdef", "gcr.io", "v1"},
+	}
+
+	for _, i := range tests {
+
+		registry, tag := parseImage(i.image)
+
+		if registry != i.registry || tag != i.tag {
+			t.Errorf("%s: result was %s %s and expected is %s %s", i.image, registry, tag, i.registry, i.tag)
+		}
+
+	}
+
+}
+
+func TestImage(t *testing.T) {
+
+	rules := `{ "rules": [
+		{
+			"name": "ImageRegistry",
+			"operator": "In",
+			"values": ["gcr.io", "registry.example.com"]
+		},
+		{
+			"name": "ImageTag",
+			"operator": "NotIn",
+			"values": ["latest"]
+		}
+	]}`
+
+	tests := []struct {
+		image    string
+		expected bool
+		errors   int
+	}{
+		{"gcr.io/project/app:v1", true, 0},
+		{"registry.example.com/app@sha256:0123456789abcdef", true, 0},
+		{"gcr.io/project/app", false, 1},
+		{"gcr.io/project/app:latest", false, 1},
+		{"nginx:1.15", false, 1},
+		{"nginx", false, 2},
+	}
+
+	a := Image{}
+	if err := json.Unmarshal([]byte(rules), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		result, err := a.isValid(i.image, field.NewPath("image"))
+
+		if result != i.expected {
+			t.Errorf("%s: result was %v and expected is %v", i.image, result, i.expected)
+		}
+
+		if n := countErrors(err); n != i.errors {
+			t.Errorf("%s: %d errors reported and expected is %d: %v", i.image, n, i.errors, err)
+		}
+
+	}
+
+}
+
+func TestSecurityContext(t *testing.T) {
+
+	rules := `{ "rules": [
+		{
+			"name": "Privileged",
+			"operator": "In",
+			"values": ["false"]
+		},
+		{
+			"name": "RunAsNonRoot",
+			"operator": "In",
+			"values": ["true"]
+		},
+		{
+			"name": "AddedCapabilities",
+			"operator": "NotIn",
+			"values": ["SYS_ADMIN", "NET_ADMIN"]
+		}
+	]}`
+
+	tests := []struct {
+		container string
+		pod       string
+		expected  bool
+		errors    int
+	}{
+		{`{ "runAsNonRoot": true }`, `{}`, true, 0},
+		{`{}`, `{ "runAsNonRoot": true }`, true, 0},
+		{`{ "runAsNonRoot": false }`, `{ "runAsNonRoot": true }`, false, 1},
+		{`{}`, `{}`, false, 1},
+		{`{ "privileged": true, "runAsNonRoot": true }`, `{}`, false, 1},
+		{`{ "runAsNonRoot": true, "capabilities": { "add": ["NET_BIND_SERVICE"] } }`, `{}`, true, 0},
+		{`{ "runAsNonRoot": true, "capabilities": { "add": ["NET_ADMIN", "SYS_ADMIN"] } }`, `{}`, false, 2},
+	}
+
+	a := SecurityContext{}
+	if err := json.Unmarshal([]byte(rules), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		c := corev1.SecurityContext{}
+		s := corev1.PodSecurityContext{}
+
+		if err := json.Unmarshal([]byte(i.container), &c); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(i.pod), &s); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		result, err := a.isValid(&c, &s, field.NewPath("securityContext"))
+
+		if result != i.expected {
+			t.Errorf("%s %s: result was %v and expected is %v", i.container, i.pod, result, i.expected)
+		}
+
+		if n := countErrors(err); n != i.errors {
+			t.Errorf("%s %s: %d errors reported and expected is %d: %v", i.container, i.pod, n, i.errors, err)
+		}
+
+	}
+
+	if ok, err := a.isValid(nil, nil, field.NewPath("securityContext")); ok || countErrors(err) != 1 {
+		t.Errorf("missing security contexts: result was %v and expected is 1 error: %v", ok, err)
+	}
+
+}
+
+func TestVolumes(t *testing.T) {
+
+	rules := `{ "rules": [
+		{
+			"name": "VolumeTypes",
+			"operator": "NotIn",
+			"values": ["hostPath"]
+		}
+	]}`
+
+	allowed := `{ "rules": [
+		{
+			"name": "HostPath",
+			"operator": "Matches",
+			"value": "/var/log(/.*)?"
+		}
+	]}`
+
+	tests := []struct {
+		rules    string
+		volumes  string
+		expected bool
+	}{
+		{rules, `[ { "name": "data", "emptyDir": {} }, { "name": "config", "configMap": { "name": "app" } } ]`, true},
+		{rules, `[ { "name": "data", "emptyDir": {} }, { "name": "root", "hostPath": { "path": "/" } } ]`, false},
+		{allowed, `[ { "name": "logs", "hostPath": { "path": "/var/log/app" } } ]`, true},
+		{allowed, `[ { "name": "root", "hostPath": { "path": "/etc" } } ]`, false},
+		{allowed, `[ { "name": "data", "emptyDir": {} } ]`, true},
+	}
+
+	for _, i := range tests {
+
+		a := Volumes{}
+		var b []corev1.Volume
+
+		if err := json.Unmarshal([]byte(i.rules), &a); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(i.volumes), &b); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		result, err := a.isValid(b, field.NewPath("volumes"))
+
+		if result != i.expected {
+			t.Errorf("%s: result was %v and expected is %v: %v", i.volumes, result, i.expected, err)
+		}
+
+	}
+
+}
+
+func TestValidatePod(t *testing.T) {
+
+	config := `
+networkPolicyValidator:
+  allowedPolicyTypes:
+  - Ingress
+podValidator:
+  rules:
+  - name: "HostNetwork"
+    operator: "In"
+    values: ["false"]
+  - name: "HostPID"
+    operator: "In"
+    values: ["false"]
+  containers:
+    image:
+      rules:
+      - name: "ImageTag"
+        operator: "NotIn"
+        values: ["latest"]
+    securityContext:
+      rules:
+      - name: "Privileged"
+        operator: "In"
+        values: ["false"]
+  volumes:
+    rules:
+    - name: "VolumeTypes"
+      operator: "NotIn"
+      values: ["hostPath"]
+profiles:
+- name: system
+  namespaces:
+  - kube-system
+  enforcement: warn
+`
+
+	pod := `{
+		"metadata": { "name": "app" },
+		"spec": {
+			"hostNetwork": true,
+			"initContainers": [ { "name": "init", "image": "busybox" } ],
+			"containers": [
+				{ "name": "app", "image": "gcr.io/project/app:v1" },
+				{ "name": "proxy", "image": "envoy:v1", "securityContext": { "privileged": true } }
+			],
+			"ephemeralContainers": [
+				{ "name": "debug", "image": "busybox:1.36", "securityContext": { "privileged": true } }
+			],
+			"volumes": [ { "name": "root", "hostPath": { "path": "/" } } ]
+		}
+	}`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	p := &corev1.Pod{}
+	if err := json.Unmarshal([]byte(pod), p); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	expected := []Violation{
+		{Field: "spec.hostNetwork", Rule: HostNetwork, Operator: OpIn, Expected: "[false]", Actual: "true"},
+		{Field: "spec.initContainers[0].image", Rule: ImageTag, Operator: OpNotIn, Expected: "[latest]", Actual: "latest"},
+		{Field: "spec.containers[1].securityContext.privileged", Rule: Privileged, Operator: OpIn, Expected: "[false]", Actual: "true"},
+		{Field: "spec.ephemeralContainers[0].securityContext.privileged", Rule: Privileged, Operator: OpIn, Expected: "[false]", Actual: "true"},
+		{Field: "spec.volumes[0]", Rule: VolumeTypes, Operator: OpNotIn, Expected: "[hostPath]", Actual: "hostPath"},
+	}
+
+	p.Namespace = "default"
+	l := v.ValidatePod(p, nil)
+
+	if len(l) != len(expected) {
+		t.Fatalf("violations were %v and expected are %v", l, expected)
+	}
+
+	for k, i := range expected {
+
+		e := l[k]
+		if e.Field != i.Field || e.Rule != i.Rule || e.Operator != i.Operator || e.Expected != i.Expected || e.Actual != i.Actual {
+			t.Errorf("violation was %+v and expected is %+v", *e, i)
+		}
+
+		if e.Enforcement != EnforcementEnforce {
+			t.Errorf("%s: enforcement was %s and expected is %s", e.Field, e.Enforcement, EnforcementEnforce)
+		}
+
+	}
+
+	// the system profile has no pod rules
+	p.Namespace = "kube-system"
+	if l := v.ValidatePod(p, nil); len(l) != 0 {
+		t.Errorf("kube-system: violations were %v and expected are none", l)
+	}
+
+}
@@ -0,0 +1,156 @@
+package admission
+
+import (
+	"fmt"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Validators holds the validators of every supported resource type
+type Validators struct {
+	NetworkPolicyValidator NetworkPolicyValidator `json:"networkPolicyValidator,omitempty"`
+	PodValidator           PodValidator           `json:"podValidator,omitempty"`
+	ServiceValidator       ServiceValidator       `json:"serviceValidator,omitempty"`
+	IngressValidator       IngressValidator       `json:"ingressValidator,omitempty"`
+	ResourcesValidator     ResourcesValidator     `json:"resourcesValidator,omitempty"`
+	RBACValidator          RBACValidator          `json:"rbacValidator,omitempty"`
+	FieldValidators        []FieldValidator       `json:"fieldValidators,omitempty"`
+	NetworkPolicyMutator   NetworkPolicyMutator   `json:"networkPolicyMutator,omitempty"`
+	NamespaceValidator     NamespaceValidator     `json:"namespaceValidator,omitempty"`
+	// Enforcement applies to the rules without an enforcement mode, defaults
+	// to enforce.
+	Enforcement Enforcement `json:"enforcement,omitempty"`
+}
+
+// compile prepares the field selectors of the validators and the except
+// CIDRs of the mutator and checks the default NetworkPolicy template, errors
+// are prefixed with path.
+func (v *Validators) compile(path string) error {
+
+	for i := range v.FieldValidators {
+		if err := v.FieldValidators[i].compile(); err != nil {
+			return fmt.Errorf("%sfieldValidators[%d]: %v", path, i, err)
+		}
+	}
+
+	if err := v.NetworkPolicyMutator.compile(); err != nil {
+		return fmt.Errorf("%snetworkPolicyMutator.%v", path, err)
+	}
+
+	if err := v.NamespaceValidator.compile(); err != nil {
+		return fmt.Errorf("%snamespaceValidator.%v", path, err)
+	}
+
+	return nil
+}
+
+// Profile is a named set of validators bound to namespaces by name, glob or
+// namespace labels.
+type Profile struct {
+	Name              string                `json:"name"`
+	Namespaces        []string              `json:"namespaces,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	Validators
+
+	selector labels.Selector
+}
+
+// compile checks the profiles and prepares their namespace selectors and
+// validators. It is called once when the validator is loaded.
+func (v *NetworkAdmissionValidator) compile() error {
+
+	if err := v.Validators.compile(""); err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+
+	for i := range v.Profiles {
+
+		p := &v.Profiles[i]
+
+		if p.Name == "" {
+			return fmt.Errorf("profiles[%d]: name is required", i)
+		}
+
+		if names[p.Name] {
+			return fmt.Errorf("profiles[%d]: duplicate profile name %q", i, p.Name)
+		}
+		names[p.Name] = true
+
+		for _, n := range p.Namespaces {
+			if _, err := path.Match(n, ""); err != nil {
+				return fmt.Errorf("profiles[%d]: invalid namespace pattern %q: %v", i, n, err)
+			}
+		}
+
+		if p.NamespaceSelector != nil {
+			s, err := metav1.LabelSelectorAsSelector(p.NamespaceSelector)
+			if err != nil {
+				return fmt.Errorf("profiles[%d]: invalid namespaceSelector: %v", i, err)
+			}
+			p.selector = s
+		}
+
+		if err := p.Validators.compile(fmt.Sprintf("profiles[%d].", i)); err != nil {
+			return err
+		}
+	}
+
+	if v.DefaultProfile != "" && !names[v.DefaultProfile] {
+		return fmt.Errorf("defaultProfile: unknown profile %q", v.DefaultProfile)
+	}
+
+	return nil
+}
+
+// profile returns the profile bound to namespace. Profiles listing the
+// namespace name take precedence over profiles matching it with a glob,
+// which take precedence over profiles selecting the namespace labels. When
+// no profile is bound to the namespace the default profile is returned, or
+// nil when there is none.
+func (v *NetworkAdmissionValidator) profile(namespace string, namespaceLabels map[string]string) *Profile {
+
+	for i, p := range v.Profiles {
+		for _, n := range p.Namespaces {
+			if n == namespace {
+				return &v.Profiles[i]
+			}
+		}
+	}
+
+	for i, p := range v.Profiles {
+		for _, n := range p.Namespaces {
+			if ok, _ := path.Match(n, namespace); ok {
+				return &v.Profiles[i]
+			}
+		}
+	}
+
+	for i, p := range v.Profiles {
+		if p.selector != nil && p.selector.Matches(labels.Set(namespaceLabels)) {
+			return &v.Profiles[i]
+		}
+	}
+
+	for i, p := range v.Profiles {
+		if p.Name == v.DefaultProfile {
+			return &v.Profiles[i]
+		}
+	}
+
+	return nil
+}
+
+// validators returns the validators that apply to namespace, the top level
+// ones are used when no profile applies.
+func (v *NetworkAdmissionValidator) validators(namespace string, namespaceLabels map[string]string) *Validators {
+
+	if p := v.profile(namespace, namespaceLabels); p != nil {
+		return &p.Validators
+	}
+
+	return &v.Validators
+}
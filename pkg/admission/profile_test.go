@@ -0,0 +1,231 @@
+package admission
+
+import (
+	"testing"
+)
+
+const profilesConfig = `
+networkPolicyValidator:
+  allowedPolicyTypes:
+  - Ingress
+  - Egress
+profiles:
+- name: system
+  namespaces:
+  - kube-system
+  networkPolicyValidator:
+    allowedPolicyTypes:
+    - Egress
+- name: teams
+  namespaces:
+  - team-*
+  networkPolicyValidator:
+    allowedPolicyTypes:
+    - Ingress
+- name: production
+  namespaceSelector:
+    matchLabels:
+      env: production
+  networkPolicyValidator:
+    allowedPolicyTypes: []
+- name: team-admin
+  namespaces:
+  - team-admin
+  networkPolicyValidator:
+    allowedPolicyTypes:
+    - Ingress
+    - Egress
+`
+
+func TestProfile(t *testing.T) {
+
+	v, err := parseAdmissionValidator([]byte(profilesConfig))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	tests := []struct {
+		namespace string
+		labels    map[string]string
+		expected  string
+	}{
+		{"kube-system", nil, "system"},
+		{"team-a", nil, "teams"},
+		{"team-admin", nil, "team-admin"},
+		{"team-a", map[string]string{"env": "production"}, "teams"},
+		{"shop", map[string]string{"env": "production"}, "production"},
+		{"shop", map[string]string{"env": "staging"}, ""},
+		{"shop", nil, ""},
+	}
+
+	for _, i := range tests {
+
+		p := v.profile(i.namespace, i.labels)
+
+		var name string
+		if p != nil {
+			name = p.Name
+		}
+
+		if name != i.expected {
+			t.Errorf("%s %v: profile was %q and expected is %q", i.namespace, i.labels, name, i.expected)
+		}
+
+	}
+
+}
+
+func TestDefaultProfile(t *testing.T) {
+
+	v, err := parseAdmissionValidator([]byte(profilesConfig + "defaultProfile: teams\n"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	if p := v.profile("shop", nil); p == nil || p.Name != "teams" {
+		t.Errorf("profile was %v and expected is teams", p)
+	}
+
+	if p := v.profile("kube-system", nil); p == nil || p.Name != "system" {
+		t.Errorf("profile was %v and expected is system", p)
+	}
+
+}
+
+func TestProfileIsValid(t *testing.T) {
+
+	v, err := parseAdmissionValidator([]byte(profilesConfig))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	tests := []struct {
+		namespace string
+		labels    map[string]string
+		expected  bool
+	}{
+		{"kube-system", nil, false},
+		{"team-a", nil, true},
+		{"shop", map[string]string{"env": "production"}, false},
+		{"shop", nil, true},
+	}
+
+	for _, i := range tests {
+
+		p := newNetworkPolicy("../../files/valid-cidr-ingress.yaml")
+		p.Spec.PolicyTypes = p.Spec.PolicyTypes[:1]
+		p.Namespace = i.namespace
+
+		if result, err := v.IsValid(p, i.labels); result != i.expected {
+			t.Errorf("%s: result was %v and expected is %v: %v", i.namespace, result, i.expected, err)
+		}
+
+	}
+
+}
+
+func TestProfileErrors(t *testing.T) {
+
+	tests := []string{
+		"profiles:\n- name: a\n- name: a\n",
+		"profiles:\n- namespaces: [a]\n",
+		"profiles:\n- name: a\n  namespaces: ['team-[']\n",
+		"profiles:\n- name: a\n  namespaceSelector:\n    matchExpressions:\n    - key: env\n      operator: Bogus\n",
+		"profiles:\n- name: a\ndefaultProfile: b\n",
+	}
+
+	for _, i := range tests {
+
+		if v, err := parseAdmissionValidator([]byte(i)); err == nil {
+			t.Errorf("%q: expected an error, got validator %+v", i, v)
+		}
+
+	}
+
+}
+
+func TestEnforcement(t *testing.T) {
+
+	config := `
+networkPolicyValidator:
+  allowedPolicyTypes:
+  - Ingress
+  ingress:
+    from:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "MaskBitsSize"
+            operator: "Ge"
+            value: 29
+            enforcement: audit
+profiles:
+- name: rollout
+  namespaces:
+  - team-*
+  enforcement: warn
+  networkPolicyValidator:
+    allowedPolicyTypes:
+    - Ingress
+    ingress:
+      from:
+        ipBlock:
+          cidr:
+            rules:
+            - name: "MaskBitsSize"
+              operator: "Ge"
+              value: 29
+              enforcement: enforce
+`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	tests := []struct {
+		namespace string
+		expected  map[string]Enforcement
+		valid     bool
+	}{
+		{
+			"shop",
+			map[string]Enforcement{
+				"spec.policyTypes[1]":                  EnforcementEnforce,
+				"spec.ingress[0].from[0].ipBlock.cidr": EnforcementAudit,
+			},
+			false,
+		},
+		{
+			"team-a",
+			map[string]Enforcement{
+				"spec.policyTypes[1]":                  EnforcementWarn,
+				"spec.ingress[0].from[0].ipBlock.cidr": EnforcementEnforce,
+			},
+			false,
+		},
+	}
+
+	for _, i := range tests {
+
+		p := newNetworkPolicy("../../files/invalid-cidr-ingress.yaml")
+		p.Namespace = i.namespace
+
+		l := v.Validate(p, nil)
+		if len(l) != len(i.expected) {
+			t.Fatalf("%s: %d violations reported and expected is %d: %v", i.namespace, len(l), len(i.expected), l)
+		}
+
+		for _, k := range l {
+			if e := i.expected[k.Field]; k.Enforcement != e {
+				t.Errorf("%s: %s enforcement was %q and expected is %q", i.namespace, k.Field, k.Enforcement, e)
+			}
+		}
+
+		if result, _ := v.IsValid(p, nil); result != i.valid {
+			t.Errorf("%s: result was %v and expected is %v", i.namespace, result, i.valid)
+		}
+
+	}
+
+}
@@ -0,0 +1,201 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateRBAC checks the policy rules of a Role or ClusterRole, or the
+// subjects of a RoleBinding or ClusterRoleBinding, with the RBAC rules of
+// the profile of namespace, which is empty for cluster scoped objects.
+func (v *NetworkAdmissionValidator) ValidateRBAC(obj runtime.Object, namespace string, namespaceLabels map[string]string) (ViolationList, error) {
+
+	validators := v.validators(namespace, namespaceLabels)
+	rbac := &validators.RBACValidator
+
+	var ok bool
+	var err error
+
+	switch o := obj.(type) {
+
+	case *rbacv1.Role:
+
+		ok, err = rbac.PolicyRules.isValid(o.Rules, field.NewPath("rules"))
+
+	case *rbacv1.ClusterRole:
+
+		ok, err = rbac.PolicyRules.isValid(o.Rules, field.NewPath("rules"))
+
+	case *rbacv1.RoleBinding:
+
+		ok, err = rbac.Bindings.isValid(o.RoleRef, o.Subjects, namespace, field.NewPath("subjects"))
+
+	case *rbacv1.ClusterRoleBinding:
+
+		ok, err = rbac.Bindings.isValid(o.RoleRef, o.Subjects, "", field.NewPath("subjects"))
+
+	default:
+
+		return nil, fmt.Errorf("%T is not an RBAC object", obj)
+
+	}
+
+	if !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement), nil
+	}
+
+	return nil, nil
+
+}
+
+// RBACValidator provides the specification of Roles, ClusterRoles and their
+// bindings
+type RBACValidator struct {
+	PolicyRules PolicyRules `json:"policyRules,omitempty"`
+	Bindings    Bindings    `json:"bindings,omitempty"`
+}
+
+// PolicyRules checks the rules of Roles and ClusterRoles
+type PolicyRules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// Verbs
+// Resources
+// APIGroups
+// ResourceVerbs
+func (v *PolicyRules) supportedRules() []RuleName {
+	return []RuleName{Verbs, Resources, APIGroups, ResourceVerbs}
+}
+
+func (v *PolicyRules) isValid(p []rbacv1.PolicyRule, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		for k, i := range p {
+
+			switch r.Name {
+
+			case Verbs:
+
+				if ok, err := r.isValidList(i.Verbs, fldPath.Index(k).Child("verbs"), "InvalidVerb", "verb"); !ok {
+					errs = append(errs, err)
+				}
+
+			case Resources:
+
+				if ok, err := r.isValidList(i.Resources, fldPath.Index(k).Child("resources"), "InvalidResource", "resource"); !ok {
+					errs = append(errs, err)
+				}
+
+			case APIGroups:
+
+				if ok, err := r.isValidList(i.APIGroups, fldPath.Index(k).Child("apiGroups"), "InvalidAPIGroup", "API group"); !ok {
+					errs = append(errs, err)
+				}
+
+			case ResourceVerbs:
+
+				// the rule applies to the policy rules granting access
+				// to the resource named by its key
+				if !grantsResource(i, r.Key) {
+					continue
+				}
+
+				if ok, err := r.isValidList(i.Verbs, fldPath.Index(k).Child("verbs"), "InvalidVerb", "verb on "+r.Key); !ok {
+					errs = append(errs, err)
+				}
+
+			}
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// grantsResource returns true when policy rule p applies to resource key,
+// written resource.group like kubectl, e.g. deployments.apps, or resource
+// for the core group, e.g. secrets or pods/exec. Wildcards match any
+// resource or group.
+func grantsResource(p rbacv1.PolicyRule, key string) bool {
+
+	resource, group := key, ""
+	if i := strings.Index(key, "."); i >= 0 {
+		resource, group = key[:i], key[i+1:]
+	}
+
+	if !contains(p.APIGroups, group) && !contains(p.APIGroups, rbacv1.APIGroupAll) {
+		return false
+	}
+
+	return contains(p.Resources, resource) || contains(p.Resources, rbacv1.ResourceAll)
+}
+
+// Bindings checks the subjects of RoleBindings and ClusterRoleBindings
+type Bindings struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// Subjects
+func (v *Bindings) supportedRules() []RuleName {
+	return []RuleName{Subjects}
+}
+
+func (v *Bindings) isValid(roleRef rbacv1.RoleRef, p []rbacv1.Subject, namespace string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	subjects := make([]string, len(p))
+	for k, i := range p {
+		subjects[k] = subjectName(i, namespace)
+	}
+
+	role := roleRef.Kind + ":" + roleRef.Name
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case Subjects:
+
+			// the rule applies to the bindings of the role named by its
+			// key, e.g. ClusterRole:cluster-admin
+			if role != r.Key {
+				continue
+			}
+
+			if ok, err := r.isValidList(subjects, fldPath, "InvalidSubject", "subject bound to "+r.Key); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// subjectName renders subject s as Kind:name, or Kind:namespace/name for
+// service accounts, e.g. Group:system:masters or ServiceAccount:kube-system/default.
+// Service accounts without a namespace are in the namespace of the binding.
+func subjectName(s rbacv1.Subject, namespace string) string {
+
+	if s.Kind == rbacv1.ServiceAccountKind {
+		if s.Namespace != "" {
+			namespace = s.Namespace
+		}
+		return fmt.Sprintf("%s:%s/%s", s.Kind, namespace, s.Name)
+	}
+
+	return fmt.Sprintf("%s:%s", s.Kind, s.Name)
+}
@@ -0,0 +1,204 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestPolicyRules(t *testing.T) {
+
+	tests := []struct {
+		rules    string
+		expected []string
+		policy   string
+	}{
+		{`{ "rules": [
+			{
+				"name": "Verbs",
+				"operator": "NotIn",
+				"values": ["*", "escalate", "bind", "impersonate"]
+			},
+			{
+				"name": "Resources",
+				"operator": "NotIn",
+				"values": ["*"]
+			}
+		]}`,
+			nil,
+			`[ { "apiGroups": [""], "resources": ["pods"], "verbs": ["get", "list"] } ]`,
+		},
+		{`{ "rules": [
+			{
+				"name": "Verbs",
+				"operator": "NotIn",
+				"values": ["*", "escalate", "bind", "impersonate"]
+			},
+			{
+				"name": "Resources",
+				"operator": "NotIn",
+				"values": ["*"]
+			}
+		]}`,
+			[]string{"rules[0].verbs[1]", "rules[1].verbs[0]", "rules[1].resources[0]"},
+			`[ { "apiGroups": [""], "resources": ["pods"], "verbs": ["get", "*"] }, { "apiGroups": ["rbac.authorization.k8s.io"], "resources": ["*"], "verbs": ["bind"] } ]`,
+		},
+		{`{ "rules": [
+			{
+				"name": "Verbs",
+				"operator": "In",
+				"values": ["get", "list", "watch"]
+			}
+		]}`,
+			[]string{"rules[0].verbs[1]"},
+			`[ { "apiGroups": ["apps"], "resources": ["deployments"], "verbs": ["get", "delete"] } ]`,
+		},
+		{`{ "rules": [
+			{
+				"name": "ResourceVerbs",
+				"operator": "NotIn",
+				"key": "secrets",
+				"values": ["*", "get", "list", "watch"]
+			}
+		]}`,
+			[]string{"rules[1].verbs[0]", "rules[2].verbs[0]", "rules[4].verbs[0]"},
+			`[ { "apiGroups": [""], "resources": ["configmaps"], "verbs": ["get"] }, { "apiGroups": [""], "resources": ["configmaps", "secrets"], "verbs": ["list"] }, { "apiGroups": [""], "resources": ["*"], "verbs": ["watch"] }, { "apiGroups": ["example.com"], "resources": ["secrets"], "verbs": ["get"] }, { "apiGroups": ["*"], "resources": ["secrets"], "verbs": ["get"] } ]`,
+		},
+		{`{ "rules": [
+			{
+				"name": "ResourceVerbs",
+				"operator": "In",
+				"key": "deployments.apps",
+				"values": ["get"]
+			}
+		]}`,
+			[]string{"rules[1].verbs[0]"},
+			`[ { "apiGroups": [""], "resources": ["deployments"], "verbs": ["delete"] }, { "apiGroups": ["apps", "extensions"], "resources": ["deployments"], "verbs": ["delete"] } ]`,
+		},
+		{`{ "rules": [
+			{
+				"name": "APIGroups",
+				"operator": "NotIn",
+				"values": ["*"]
+			}
+		]}`,
+			[]string{"rules[0].apiGroups[0]"},
+			`[ { "apiGroups": ["*"], "resources": ["pods"], "verbs": ["get"] } ]`,
+		},
+	}
+
+	for _, i := range tests {
+
+		a := PolicyRules{}
+		var b []rbacv1.PolicyRule
+
+		if err := json.Unmarshal([]byte(i.rules), &a); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(i.policy), &b); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		_, err := a.isValid(b, field.NewPath("rules"))
+		l, _ := err.(ViolationList)
+
+		if len(l) != len(i.expected) {
+			t.Errorf("%s: violations were %v and expected are %v", i.policy, err, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if l[k].Field != e {
+				t.Errorf("%s: violation was %v and expected field is %s", i.policy, l[k], e)
+			}
+		}
+
+	}
+
+}
+
+func TestValidateRBAC(t *testing.T) {
+
+	config := `
+rbacValidator:
+  bindings:
+    rules:
+    - name: "Subjects"
+      operator: "In"
+      key: "ClusterRole:cluster-admin"
+      values:
+      - "Group:system:masters"
+      - "ServiceAccount:kube-system/cluster-admin"
+profiles:
+- name: teams
+  namespaces:
+  - team-*
+  rbacValidator:
+    policyRules:
+      rules:
+      - name: "Verbs"
+        operator: "In"
+        values: ["get", "list", "watch"]
+`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	masters := rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:masters"}
+	admin := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "kube-system", Name: "cluster-admin"}
+	developer := rbacv1.Subject{Kind: rbacv1.UserKind, Name: "developer"}
+	clusterAdmin := rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"}
+	roleAdmin := rbacv1.RoleRef{Kind: "Role", Name: "cluster-admin"}
+	localAdmin := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "cluster-admin"}
+	view := rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"}
+	edit := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "delete"}}}
+
+	tests := []struct {
+		obj       runtime.Object
+		namespace string
+		expected  []string
+	}{
+		{&rbacv1.ClusterRoleBinding{RoleRef: clusterAdmin, Subjects: []rbacv1.Subject{masters, admin}}, "", nil},
+		{&rbacv1.ClusterRoleBinding{RoleRef: clusterAdmin, Subjects: []rbacv1.Subject{masters, developer}}, "", []string{"subjects[1]"}},
+		{&rbacv1.ClusterRoleBinding{RoleRef: view, Subjects: []rbacv1.Subject{developer}}, "", nil},
+		{&rbacv1.RoleBinding{RoleRef: clusterAdmin, Subjects: []rbacv1.Subject{developer}}, "shop", []string{"subjects[0]"}},
+		{&rbacv1.RoleBinding{RoleRef: roleAdmin, Subjects: []rbacv1.Subject{developer}}, "shop", nil},
+		{&rbacv1.RoleBinding{RoleRef: clusterAdmin, Subjects: []rbacv1.Subject{localAdmin}}, "kube-system", nil},
+		{&rbacv1.RoleBinding{RoleRef: clusterAdmin, Subjects: []rbacv1.Subject{localAdmin}}, "shop", []string{"subjects[0]"}},
+		{&rbacv1.Role{Rules: edit}, "team-a", []string{"rules[0].verbs[1]"}},
+		{&rbacv1.Role{Rules: edit}, "shop", nil},
+		{&rbacv1.ClusterRole{Rules: edit}, "", nil},
+	}
+
+	for _, i := range tests {
+
+		l, err := v.ValidateRBAC(i.obj, i.namespace, nil)
+		if err != nil {
+			t.Fatalf("%T: error %v", i.obj, err)
+		}
+
+		if len(l) != len(i.expected) {
+			t.Errorf("%T %s: violations were %v and expected are %v", i.obj, i.namespace, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if l[k].Field != e {
+				t.Errorf("%T %s: violation was %v and expected field is %s", i.obj, i.namespace, l[k], e)
+			}
+		}
+
+	}
+
+	if _, err := v.ValidateRBAC(&corev1.Pod{}, "", nil); err == nil {
+		t.Errorf("expected an error for a Pod")
+	}
+
+}
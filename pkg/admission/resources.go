@@ -0,0 +1,149 @@
+package admission
+
+import (
+	"fmt"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ResourcesValidator provides the specification of the compute resources of
+// the containers and init containers of a pod spec
+type ResourcesValidator struct {
+	CPU    ContainerResource `json:"cpu,omitempty"`
+	Memory ContainerResource `json:"memory,omitempty"`
+}
+
+func (v *ResourcesValidator) isValid(p *corev1.PodSpec, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	if ok, err := v.isValidContainers(p.InitContainers, fldPath.Child("initContainers")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := v.isValidContainers(p.Containers, fldPath.Child("containers")); !ok {
+		errs = append(errs, err)
+	}
+
+	return aggregate(errs)
+
+}
+
+func (v *ResourcesValidator) isValidContainers(p []corev1.Container, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for k, c := range p {
+
+		if ok, err := v.CPU.isValid(&c.Resources, corev1.ResourceCPU, fldPath.Index(k).Child("resources")); !ok {
+			errs = append(errs, err)
+		}
+
+		if ok, err := v.Memory.isValid(&c.Resources, corev1.ResourceMemory, fldPath.Index(k).Child("resources")); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+// ContainerResource checks the request and the limit of a compute resource
+// of containers
+type ContainerResource struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// ResourceRequest
+// ResourceLimit
+// LimitRequestRatio
+func (v *ContainerResource) supportedRules() []RuleName {
+	return []RuleName{ResourceRequest, ResourceLimit, LimitRequestRatio}
+}
+
+func (v *ContainerResource) isValid(p *corev1.ResourceRequirements, name corev1.ResourceName, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ResourceRequest:
+
+			if ok, err := r.isValidQuantity(p.Requests, name, fldPath.Child("requests").Key(string(name)), "request"); !ok {
+				errs = append(errs, err)
+			}
+
+		case ResourceLimit:
+
+			if ok, err := r.isValidQuantity(p.Limits, name, fldPath.Child("limits").Key(string(name)), "limit"); !ok {
+				errs = append(errs, err)
+			}
+
+		case LimitRequestRatio:
+
+			if ok, err := r.isValidRatio(p, name, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// isValidQuantity checks the quantity of resource name in l. Exists and
+// DoesNotExist check its presence and every other operator requires it.
+func (v *Rule) isValidQuantity(l corev1.ResourceList, name corev1.ResourceName, fldPath *field.Path, subject string) (bool, error) {
+
+	q, found := l[name]
+
+	switch v.Operator {
+
+	case OpExists, OpDoesNotExist:
+
+		if found != (v.Operator == OpExists) {
+			return false, v.violation(fldPath, q.String(), fmt.Sprintf(
+				"error InvalidResources: %s %s must satisfy %s", name, subject, v.Operator))
+		}
+
+		return true, nil
+
+	}
+
+	if !found {
+		return false, v.violation(fldPath, "", fmt.Sprintf(
+			"error InvalidResources: %s %s is required", name, subject))
+	}
+
+	return v.check(&q, fldPath, "InvalidResources", string(name)+" "+subject)
+
+}
+
+// isValidRatio checks the ratio between the limit and the request of
+// resource name, it is skipped unless both are set.
+func (v *Rule) isValidRatio(p *corev1.ResourceRequirements, name corev1.ResourceName, fldPath *field.Path) (bool, error) {
+
+	request, found := p.Requests[name]
+	if !found || request.IsZero() {
+		return true, nil
+	}
+
+	limit, found := p.Limits[name]
+	if !found {
+		return true, nil
+	}
+
+	ratio := limit.AsApproximateFloat64() / request.AsApproximateFloat64()
+	q := resource.NewMilliQuantity(int64(math.Round(ratio*1000)), resource.DecimalSI)
+
+	return v.check(q, fldPath, "InvalidResources", string(name)+" limit/request ratio")
+
+}
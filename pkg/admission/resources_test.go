@@ -0,0 +1,202 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestContainerResource(t *testing.T) {
+
+	rules := `{ "rules": [
+		{
+			"name": "ResourceRequest",
+			"operator": "Ge",
+			"value": "100m"
+		},
+		{
+			"name": "ResourceLimit",
+			"operator": "Le",
+			"value": "2"
+		},
+		{
+			"name": "LimitRequestRatio",
+			"operator": "Le",
+			"value": "4"
+		}
+	]}`
+
+	tests := []struct {
+		resources string
+		expected  bool
+		errors    int
+	}{
+		{`{ "requests": { "cpu": "250m" }, "limits": { "cpu": "1" } }`, true, 0},
+		{`{ "requests": { "cpu": "500m" }, "limits": { "cpu": "2" } }`, true, 0},
+		{`{ "requests": { "cpu": "50m" }, "limits": { "cpu": "1" } }`, false, 2},
+		{`{ "requests": { "cpu": "250m" }, "limits": { "cpu": "1500m" } }`, false, 1},
+		{`{ "requests": { "cpu": "1" }, "limits": { "cpu": "3" } }`, false, 1},
+		{`{ "limits": { "cpu": "1" } }`, false, 1},
+		{`{}`, false, 2},
+	}
+
+	a := ContainerResource{}
+	if err := json.Unmarshal([]byte(rules), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		b := corev1.ResourceRequirements{}
+		if err := json.Unmarshal([]byte(i.resources), &b); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		result, err := a.isValid(&b, corev1.ResourceCPU, field.NewPath("resources"))
+
+		if result != i.expected {
+			t.Errorf("%s: result was %v and expected is %v", i.resources, result, i.expected)
+		}
+
+		if n := countErrors(err); n != i.errors {
+			t.Errorf("%s: %d errors reported and expected is %d: %v", i.resources, n, i.errors, err)
+		}
+
+	}
+
+}
+
+func TestResourceExists(t *testing.T) {
+
+	rules := `{ "rules": [
+		{
+			"name": "ResourceLimit",
+			"operator": "Exists"
+		},
+		{
+			"name": "ResourceRequest",
+			"operator": "Exists"
+		}
+	]}`
+
+	a := ContainerResource{}
+	if err := json.Unmarshal([]byte(rules), &a); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	b := corev1.ResourceRequirements{}
+	if err := json.Unmarshal([]byte(`{ "requests": { "memory": "64Mi" } }`), &b); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	result, err := a.isValid(&b, corev1.ResourceMemory, field.NewPath("resources"))
+	if result || countErrors(err) != 1 {
+		t.Fatalf("result was %v and expected is 1 error: %v", result, err)
+	}
+
+	if v := err.(ViolationList)[0]; v.Field != "resources.limits[memory]" {
+		t.Errorf("violation was %v and expected field is resources.limits[memory]", v)
+	}
+
+}
+
+func TestPodSpec(t *testing.T) {
+
+	spec := corev1.PodTemplateSpec{Spec: corev1.PodSpec{NodeName: "node"}}
+
+	tests := []struct {
+		obj      runtime.Object
+		expected string
+	}{
+		{&corev1.Pod{Spec: spec.Spec}, "spec"},
+		{&appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: spec}}, "spec.template.spec"},
+		{&appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Template: spec}}, "spec.template.spec"},
+		{&appsv1.DaemonSet{Spec: appsv1.DaemonSetSpec{Template: spec}}, "spec.template.spec"},
+		{&appsv1.ReplicaSet{Spec: appsv1.ReplicaSetSpec{Template: spec}}, "spec.template.spec"},
+		{&batchv1.Job{Spec: batchv1.JobSpec{Template: spec}}, "spec.template.spec"},
+		{&batchv1.CronJob{Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: spec}}}}, "spec.jobTemplate.spec.template.spec"},
+		{&batchv1beta1.CronJob{Spec: batchv1beta1.CronJobSpec{JobTemplate: batchv1beta1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: spec}}}}, "spec.jobTemplate.spec.template.spec"},
+	}
+
+	for _, i := range tests {
+
+		p, fldPath, err := PodSpec(i.obj)
+		if err != nil {
+			t.Errorf("%T: error %v", i.obj, err)
+			continue
+		}
+
+		if p.NodeName != "node" || fldPath.String() != i.expected {
+			t.Errorf("%T: pod spec was %s and expected is %s", i.obj, fldPath, i.expected)
+		}
+
+	}
+
+	if _, _, err := PodSpec(&corev1.Service{}); err == nil {
+		t.Errorf("expected an error for a Service")
+	}
+
+}
+
+func TestValidateWorkload(t *testing.T) {
+
+	config := `
+resourcesValidator:
+  memory:
+    rules:
+    - name: "ResourceLimit"
+      operator: "Le"
+      value: "1Gi"
+profiles:
+- name: batch
+  namespaces:
+  - batch
+  enforcement: warn
+  resourcesValidator:
+    memory:
+      rules:
+      - name: "ResourceLimit"
+        operator: "Le"
+        value: "8Gi"
+`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	deployment := `{ "spec": { "template": { "spec": {
+		"initContainers": [ { "name": "init", "resources": { "limits": { "memory": "128Mi" } } } ],
+		"containers": [ { "name": "app", "resources": { "limits": { "memory": "2Gi" } } } ]
+	} } } }`
+
+	d := &appsv1.Deployment{}
+	if err := json.Unmarshal([]byte(deployment), d); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	l, err := v.ValidateWorkload(d, "default", nil)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	if len(l) != 1 || l[0].Field != "spec.template.spec.containers[0].resources.limits[memory]" || l[0].Enforcement != EnforcementEnforce {
+		t.Errorf("violations were %v and expected is an enforced memory limit violation", l)
+	}
+
+	if l, _ := v.ValidateWorkload(d, "batch", nil); len(l) != 0 {
+		t.Errorf("batch: violations were %v and expected are none", l)
+	}
+
+	p := &corev1.Pod{Spec: d.Spec.Template.Spec}
+	if l := v.ValidatePod(p, nil); len(l) != 1 || l[0].Field != "spec.containers[0].resources.limits[memory]" {
+		t.Errorf("pod: violations were %v and expected is a memory limit violation", l)
+	}
+
+}
@@ -1,10 +1,15 @@
 package admission
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 // RuleName is ..
@@ -12,109 +17,735 @@ type RuleName string
 
 // Rules
 const (
-	MaskBitsSize RuleName = "MaskBitsSize"
-	ListSize     RuleName = "ListSize"
-	LabelValues  RuleName = "LabelValues"
-	LabelCount   RuleName = "LabelCount"
-	PortNumber   RuleName = "PortNumber"
+	MaskBitsSize   RuleName = "MaskBitsSize"
+	MaskBitsSizeV4 RuleName = "MaskBitsSizeV4"
+	MaskBitsSizeV6 RuleName = "MaskBitsSizeV6"
+	AddressFamily  RuleName = "AddressFamily"
+	ListSize       RuleName = "ListSize"
+	LabelValues    RuleName = "LabelValues"
+	LabelCount     RuleName = "LabelCount"
+	PortNumber     RuleName = "PortNumber"
+
+	ImageRegistry     RuleName = "ImageRegistry"
+	ImageTag          RuleName = "ImageTag"
+	Privileged        RuleName = "Privileged"
+	RunAsNonRoot      RuleName = "RunAsNonRoot"
+	AddedCapabilities RuleName = "AddedCapabilities"
+	HostNetwork       RuleName = "HostNetwork"
+	HostPID           RuleName = "HostPID"
+	VolumeTypes       RuleName = "VolumeTypes"
+	HostPath          RuleName = "HostPath"
+
+	ServiceType         RuleName = "ServiceType"
+	WithinCIDRs         RuleName = "WithinCIDRs"
+	NotOverlappingCIDRs RuleName = "NotOverlappingCIDRs"
+
+	DomainSuffix  RuleName = "DomainSuffix"
+	WildcardHosts RuleName = "WildcardHosts"
+	RequireTLS    RuleName = "RequireTLS"
+
+	ResourceRequest   RuleName = "ResourceRequest"
+	ResourceLimit     RuleName = "ResourceLimit"
+	LimitRequestRatio RuleName = "LimitRequestRatio"
+
+	Verbs         RuleName = "Verbs"
+	Resources     RuleName = "Resources"
+	APIGroups     RuleName = "APIGroups"
+	ResourceVerbs RuleName = "ResourceVerbs"
+	Subjects      RuleName = "Subjects"
+
+	FieldValue RuleName = "FieldValue"
+
+	PodSelectorChange RuleName = "PodSelectorChange"
+	CIDRWidening      RuleName = "CIDRWidening"
+	ExceptRemoval     RuleName = "ExceptRemoval"
+	Widening          RuleName = "Widening"
+
+	ExceptWithinCIDR   RuleName = "ExceptWithinCIDR"
+	OverlappingExcepts RuleName = "OverlappingExcepts"
+
+	Protocol      RuleName = "Protocol"
+	NamedPorts    RuleName = "NamedPorts"
+	PortName      RuleName = "PortName"
+	PortRangeSize RuleName = "PortRangeSize"
+	AllPorts      RuleName = "AllPorts"
 )
 
+// ruleOperators lists the operators supported by each rule
+var ruleOperators = map[RuleName][]Operator{
+	MaskBitsSize:   numericOperators,
+	MaskBitsSizeV4: numericOperators,
+	MaskBitsSizeV6: numericOperators,
+	AddressFamily:  {OpIn, OpNotIn},
+	ListSize:       numericOperators,
+	LabelCount:     numericOperators,
+	PortNumber:     append([]Operator{OpIn, OpNotIn}, numericOperators...),
+	LabelValues:    {OpIn, OpNotIn, OpExists, OpDoesNotExist, OpMatches},
+
+	ImageRegistry:     {OpIn, OpNotIn, OpMatches},
+	ImageTag:          {OpIn, OpNotIn, OpMatches},
+	Privileged:        {OpIn, OpNotIn},
+	RunAsNonRoot:      {OpIn, OpNotIn},
+	AddedCapabilities: {OpIn, OpNotIn},
+	HostNetwork:       {OpIn, OpNotIn},
+	HostPID:           {OpIn, OpNotIn},
+	VolumeTypes:       {OpIn, OpNotIn},
+	HostPath:          {OpIn, OpNotIn, OpMatches},
+
+	ServiceType:         {OpIn, OpNotIn},
+	WithinCIDRs:         {OpIn, OpNotIn},
+	NotOverlappingCIDRs: {OpIn},
+
+	DomainSuffix:  {OpIn, OpNotIn},
+	WildcardHosts: {OpIn, OpNotIn},
+	RequireTLS:    {OpIn, OpMatches},
+
+	ResourceRequest:   append([]Operator{OpExists, OpDoesNotExist}, numericOperators...),
+	ResourceLimit:     append([]Operator{OpExists, OpDoesNotExist}, numericOperators...),
+	LimitRequestRatio: numericOperators,
+
+	Verbs:         {OpIn, OpNotIn},
+	Resources:     {OpIn, OpNotIn},
+	APIGroups:     {OpIn, OpNotIn},
+	ResourceVerbs: {OpIn, OpNotIn},
+	Subjects:      {OpIn, OpNotIn, OpMatches},
+
+	FieldValue: append([]Operator{OpIn, OpNotIn, OpMatches}, numericOperators...),
+
+	PodSelectorChange: {OpIn, OpNotIn},
+	CIDRWidening:      {OpIn, OpNotIn},
+	ExceptRemoval:     {OpIn, OpNotIn},
+	Widening:          {OpIn, OpNotIn},
+
+	ExceptWithinCIDR:   {OpIn, OpNotIn},
+	OverlappingExcepts: {OpIn, OpNotIn},
+
+	Protocol:      {OpIn, OpNotIn},
+	NamedPorts:    {OpIn, OpNotIn},
+	PortName:      {OpIn, OpNotIn, OpMatches},
+	PortRangeSize: numericOperators,
+	AllPorts:      {OpIn, OpNotIn},
+}
+
+// keyedRules apply to the item named by the rule key, e.g. a label
+var keyedRules = []RuleName{LabelValues, ResourceVerbs, Subjects}
+
+// quantityRules compare a value with a quantity, e.g. 500m or 1Gi
+var quantityRules = []RuleName{ResourceRequest, ResourceLimit, LimitRequestRatio}
+
+// InvalidCIDR is the rule of the violations reported for malformed CIDRs, it
+// is always checked and can't be configured
+const InvalidCIDR RuleName = "InvalidCIDR"
+
+// invalidCIDR returns the violation of malformed CIDR c
+func invalidCIDR(fldPath *field.Path, c string) *Violation {
+
+	return &Violation{
+		Field:   fldPath.String(),
+		Rule:    InvalidCIDR,
+		Actual:  strconv.Quote(c),
+		Message: "error InvalidCIDR: must be a valid CIDR, e.g. 10.0.0.0/16",
+	}
+}
+
+// InvalidPortRange is the rule of the violations reported for port ranges
+// ending before their first port, it is always checked and can't be
+// configured
+const InvalidPortRange RuleName = "InvalidPortRange"
+
+// invalidPortRange returns the violation of a range from port to endPort
+func invalidPortRange(fldPath *field.Path, port, endPort int) *Violation {
+
+	return &Violation{
+		Field:   fldPath.String(),
+		Rule:    InvalidPortRange,
+		Actual:  strconv.Itoa(endPort),
+		Message: fmt.Sprintf("error InvalidPortRange: endPort must be greater than or equal to port %d", port),
+	}
+}
+
+// cidrRules compare a value with a list of CIDRs
+var cidrRules = []RuleName{WithinCIDRs, NotOverlappingCIDRs}
+
+// Address families of the AddressFamily rule
+const (
+	IPv4 = "IPv4"
+	IPv6 = "IPv6"
+)
+
+// familyRules compare an address family with IPv4 or IPv6
+var familyRules = []RuleName{AddressFamily}
+
+// booleanRules compare a boolean setting with "true" or "false"
+var booleanRules = []RuleName{Privileged, RunAsNonRoot, HostNetwork, HostPID, WildcardHosts,
+	PodSelectorChange, CIDRWidening, ExceptRemoval, Widening, ExceptWithinCIDR, OverlappingExcepts,
+	NamedPorts, AllPorts}
+
+// portRules compare a port with a list of port numbers
+var portRules = []RuleName{PortNumber}
+
+// Protocols of the Protocol rule
+var protocols = []string{"TCP", "UDP", "SCTP"}
+
 // Rule is ...
 type Rule struct {
 	Name     RuleName           `json:"name"`
 	Operator Operator           `json:"operator"`
 	Key      string             `json:"key"`
 	Value    intstr.IntOrString `json:"value"`
+	Values   []string           `json:"values,omitempty"`
+	// Enforcement overrides the enforcement mode of the enclosing profile
+	Enforcement Enforcement `json:"enforcement,omitempty"`
+
+	// pattern is the compiled Value of the Matches rules
+	pattern *regexp.Regexp
 }
 
-func (v *Rule) isValidMask(c string) (bool, error) {
+// UnmarshalJSON decodes a rule and compiles the pattern of Matches rules once
+// for every object it checks. Invalid patterns are rejected by checkConfig,
+// a rule decoded without it reports the pattern error when it is evaluated.
+func (v *Rule) UnmarshalJSON(b []byte) error {
 
-	_, network, _ := net.ParseCIDR(c)
-	m, _ := network.Mask.Size()
+	type rule Rule
+	if err := json.Unmarshal(b, (*rule)(v)); err != nil {
+		return err
+	}
+
+	v.pattern = nil
+	if v.Operator == OpMatches {
+		v.pattern, _ = compilePattern(v.Value.String())
+	}
+
+	return nil
+}
+
+// operand returns the configured value the rule operator compares against:
+// Values for set operators, Key for key operators, the compiled pattern for
+// Matches and Value otherwise.
+func (v *Rule) operand() interface{} {
+
+	switch v.Operator {
+
+	case OpIn, OpNotIn:
+
+		return v.Values
 
-	ok, err := operatorExec(m, v.Value.IntValue(), v.Operator)
+	case OpExists, OpDoesNotExist:
+
+		return v.Key
+
+	case OpMatches:
+
+		if v.pattern != nil {
+			return v.pattern
+		}
+
+	}
+
+	return v.Value
+}
+
+// expected renders the rule operand for error messages.
+func (v *Rule) expected() string {
+
+	switch v.Operator {
+
+	case OpIn, OpNotIn:
+
+		return "[" + strings.Join(v.Values, ", ") + "]"
+
+	case OpExists, OpDoesNotExist:
+
+		return v.Key
+
+	}
+
+	return v.Value.String()
+}
+
+// check runs the rule operator against x and reports a violation at fldPath
+// when it does not hold.
+func (v *Rule) check(x interface{}, fldPath *field.Path, reason, subject string) (bool, error) {
+
+	ok, err := operatorExec(x, v.operand(), v.Operator)
 	if err != nil {
-		return false, err
+		return false, v.violation(fldPath, x, err.Error())
 	}
 
 	if !ok {
-		return false, fmt.Errorf(
-			"error InvalidMaskSize: mask size must be %s %v",
-			v.Operator, v.Value.IntValue())
+		return false, v.violation(fldPath, x, fmt.Sprintf(
+			"error %s: %s must be %s %v",
+			reason, subject, v.Operator, v.expected()))
 	}
 
 	return true, nil
 
 }
 
-func (v *Rule) isValidMaskList(l []string) (bool, error) {
+func (v *Rule) violation(fldPath *field.Path, actual interface{}, msg string) *Violation {
 
-	for _, i := range l {
+	return &Violation{
+		Field:       fldPath.String(),
+		Rule:        v.Name,
+		Operator:    v.Operator,
+		Expected:    v.expected(),
+		Actual:      fmt.Sprint(actual),
+		Message:     msg,
+		Enforcement: v.Enforcement,
+	}
+}
 
-		ok, err := v.isValidMask(i)
-		if err != nil {
-			return false, err
+func (v *Rule) isValidMask(c string, fldPath *field.Path) (bool, error) {
+
+	_, network, err := net.ParseCIDR(c)
+	if err != nil {
+		return false, invalidCIDR(fldPath, c)
+	}
+
+	m, _ := network.Mask.Size()
+
+	return v.check(m, fldPath, "InvalidMaskSize", "mask size")
+
+}
+
+func (v *Rule) isValidMaskList(l []string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for i, c := range l {
+
+		if ok, err := v.isValidMask(c, fldPath.Index(i)); !ok {
+			errs = append(errs, err)
 		}
 
-		if !ok {
-			return false, fmt.Errorf(
-				"error InvalidMaskSize: mask size must be %s %v",
-				v.Operator, v.Value.IntValue())
+	}
+
+	return aggregate(errs)
+
+}
+
+// isValidFamilyMask checks the mask size of CIDR c when it belongs to address
+// family, the CIDRs of the other family are ignored.
+func (v *Rule) isValidFamilyMask(c, family string, fldPath *field.Path) (bool, error) {
+
+	_, network, err := net.ParseCIDR(c)
+	if err != nil {
+		return false, invalidCIDR(fldPath, c)
+	}
+
+	if addressFamily(network) != family {
+		return true, nil
+	}
+
+	m, _ := network.Mask.Size()
+
+	return v.check(m, fldPath, "InvalidMaskSize", family+" mask size")
+
+}
+
+func (v *Rule) isValidFamilyMaskList(l []string, family string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for i, c := range l {
+
+		if ok, err := v.isValidFamilyMask(c, family, fldPath.Index(i)); !ok {
+			errs = append(errs, err)
 		}
 
 	}
 
+	return aggregate(errs)
+
+}
+
+// isValidAddressFamily checks the address family of c, an IP or a CIDR
+func (v *Rule) isValidAddressFamily(c string, fldPath *field.Path) (bool, error) {
+
+	network, err := parseNetwork(c)
+	if err != nil {
+		return false, v.violation(fldPath, c, fmt.Sprintf(
+			"error InvalidAddress: %q is not a valid IP address or CIDR", c))
+	}
+
+	return v.check(addressFamily(network), fldPath, "InvalidAddressFamily", "address family")
+
+}
+
+func (v *Rule) isValidAddressFamilyList(l []string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for i, c := range l {
+
+		if ok, err := v.isValidAddressFamily(c, fldPath.Index(i)); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+// addressFamily returns IPv4 or IPv6, the family of network n. IPv4-mapped
+// IPv6 CIDRs, e.g. ::ffff:10.0.0.0/104, are IPv6 CIDRs.
+func addressFamily(n *net.IPNet) string {
+
+	if _, bits := n.Mask.Size(); bits == 8*net.IPv4len {
+		return IPv4
+	}
+
+	return IPv6
+}
+
+// isWithinCIDRs checks that address c, an IP or a CIDR, is within one of the
+// rule CIDRs for In and outside all of them for NotIn.
+func (v *Rule) isWithinCIDRs(c string, fldPath *field.Path) (bool, error) {
+
+	network, err := parseNetwork(c)
+	if err != nil {
+		return false, v.violation(fldPath, c, fmt.Sprintf(
+			"error InvalidAddress: %q is not a valid IP address or CIDR", c))
+	}
+
+	within := false
+	for _, i := range v.Values {
+		if _, n, err := net.ParseCIDR(i); err == nil && containsNetwork(n, network) {
+			within = true
+			break
+		}
+	}
+
+	if within != (v.Operator == OpIn) {
+		return false, v.violation(fldPath, c, fmt.Sprintf(
+			"error InvalidAddress: address must be %s %v", v.Operator, v.expected()))
+	}
+
 	return true, nil
 
 }
 
-func (v *Rule) isValidListSize(s int) (bool, error) {
+func (v *Rule) isWithinCIDRsList(l []string, fldPath *field.Path) (bool, error) {
 
-	ok, err := operatorExec(s, v.Value.IntValue(), v.Operator)
+	var errs []error
+
+	for i, c := range l {
+
+		if ok, err := v.isWithinCIDRs(c, fldPath.Index(i)); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+// isNotOverlappingCIDRs checks that address c, an IP or a CIDR, shares no
+// address with the rule CIDRs. The rule CIDRs within one of the except
+// entries of c are not reachable through c and are ignored.
+func (v *Rule) isNotOverlappingCIDRs(c string, except []string, fldPath *field.Path) (bool, error) {
+
+	network, err := parseNetwork(c)
 	if err != nil {
-		return false, err
+		return false, v.violation(fldPath, c, fmt.Sprintf(
+			"error InvalidAddress: %q is not a valid IP address or CIDR", c))
 	}
 
-	if !ok {
-		return false, fmt.Errorf(
-			"error InvalidListSize: list size must be %s %v ",
-			v.Operator, v.Value.IntValue())
+	var overlapping []string
+	for _, i := range v.Values {
+		if _, n, err := net.ParseCIDR(i); err == nil && overlapsNetwork(network, n) && !isExcluded(except, n) {
+			overlapping = append(overlapping, i)
+		}
+	}
+
+	if len(overlapping) > 0 {
+		return false, v.violation(fldPath, c, fmt.Sprintf(
+			"error OverlappingAddress: address must not overlap %s", strings.Join(overlapping, ", ")))
 	}
 
 	return true, nil
 
 }
 
-func (v *Rule) isValidPort(s int) (bool, error) {
+// parseNetwork parses c as a CIDR or as a single IP address
+func parseNetwork(c string) (*net.IPNet, error) {
+
+	if _, n, err := net.ParseCIDR(c); err == nil {
+		return n, nil
+	}
+
+	ip := net.ParseIP(c)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", c)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// containsNetwork returns true when network b is within network a
+func containsNetwork(a, b *net.IPNet) bool {
+
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+
+	return aBits == bBits && aOnes <= bOnes && a.Contains(b.IP)
+}
+
+// overlapsNetwork returns true when networks a and b share addresses
+func overlapsNetwork(a, b *net.IPNet) bool {
+
+	return len(a.IP) == len(b.IP) && (a.Contains(b.IP) || b.Contains(a.IP))
+}
+
+// sameNetwork returns true when networks a and b are the same CIDR
+func sameNetwork(a, b *net.IPNet) bool {
+
+	return containsNetwork(a, b) && containsNetwork(b, a)
+}
+
+// isExcluded returns true when network n is within one of the except entries
+func isExcluded(except []string, n *net.IPNet) bool {
+
+	for _, e := range except {
+		if _, network, err := net.ParseCIDR(e); err == nil && containsNetwork(network, n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isExceptWithinCIDR checks every except entry of an ipBlock, the rule value
+// is "true" when the entry is a strict subset of the ipBlock CIDR c, e.g.
+// ExceptWithinCIDR In [true] rejects the misplaced entries.
+func (v *Rule) isExceptWithinCIDR(c string, except []string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
 
-	ok, err := operatorExec(s, v.Value.IntValue(), v.Operator)
+	_, cidr, cidrErr := net.ParseCIDR(c)
+
+	for i, e := range except {
+
+		_, n, err := net.ParseCIDR(e)
+		within := cidrErr == nil && err == nil && containsNetwork(cidr, n) && !sameNetwork(cidr, n)
+
+		msg := fmt.Sprintf("except entry %s is not within cidr %s", e, c)
+		if within {
+			msg = fmt.Sprintf("except entry %s is within cidr %s", e, c)
+		}
+
+		if ok, err := v.checkExcept(within, fldPath.Index(i), e, msg); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+// isOverlappingExcepts checks every except entry against the previous ones,
+// the rule value is "true" when the entry duplicates or overlaps one of them,
+// e.g. OverlappingExcepts In [false] rejects the redundant entries.
+func (v *Rule) isOverlappingExcepts(except []string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	networks := make([]*net.IPNet, len(except))
+	for i, e := range except {
+		_, networks[i], _ = net.ParseCIDR(e)
+	}
+
+	for i, e := range except {
+
+		msg := fmt.Sprintf("except entry %s overlaps no other entry", e)
+		overlapping := false
+
+		for j := 0; j < i && networks[i] != nil; j++ {
+
+			if networks[j] == nil || !overlapsNetwork(networks[i], networks[j]) {
+				continue
+			}
+
+			overlapping = true
+			if sameNetwork(networks[i], networks[j]) {
+				msg = fmt.Sprintf("except entry %s duplicates %s", e, fldPath.Index(j))
+			} else {
+				msg = fmt.Sprintf("except entry %s overlaps %s %s", e, fldPath.Index(j), except[j])
+			}
+			break
+		}
+
+		if ok, err := v.checkExcept(overlapping, fldPath.Index(i), e, msg); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+// checkExcept compares the value of a boolean rule on except entry e with the
+// rule operand, msg describes the entry.
+func (v *Rule) checkExcept(value bool, fldPath *field.Path, e, msg string) (bool, error) {
+
+	ok, err := operatorExec(strconv.FormatBool(value), v.operand(), v.Operator)
 	if err != nil {
-		return false, err
+		return false, v.violation(fldPath, e, err.Error())
 	}
 
 	if !ok {
-		return false, fmt.Errorf(
-			"error InvalidPortNumber: port number must be %s %v ",
-			v.Operator, v.Value.IntValue())
+		return false, v.violation(fldPath, e, fmt.Sprintf("error Invalid%s: %s", v.Name, msg))
 	}
 
 	return true, nil
 
 }
 
-func (v *Rule) isValidLabelValues(labels map[string]string) (bool, error) {
+// isValidChange checks a change made by an update, the rule value is "true"
+// when the change is allowed, e.g. CIDRWidening In [false] forbids widening
+// a CIDR.
+func (v *Rule) isValidChange(c policyChange) (bool, error) {
 
-	ok, err := operatorExec(len(labels), v.Value.IntValue(), v.Operator)
+	ok, err := operatorExec("true", v.operand(), v.Operator)
 	if err != nil {
-		return false, err
+		return false, v.violation(c.FldPath, c.String(), err.Error())
 	}
 
 	if !ok {
-		return false, fmt.Errorf(
-			"error InvalidLabelCount: the numbers of labels must be %s %v",
-			v.Operator, v.Value.IntValue())
+		return false, v.violation(c.FldPath, c.String(), fmt.Sprintf(
+			"error Invalid%s: %s is not allowed on update", v.Name, c.Type))
 	}
 
 	return true, nil
 
 }
+
+// isValidList checks every element of l, the violations are reported with
+// the element index.
+func (v *Rule) isValidList(l []string, fldPath *field.Path, reason, subject string) (bool, error) {
+
+	var errs []error
+
+	for i, e := range l {
+
+		if ok, err := v.check(e, fldPath.Index(i), reason, subject); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+func (v *Rule) isValidListSize(s int, fldPath *field.Path) (bool, error) {
+
+	return v.check(s, fldPath, "InvalidListSize", "list size")
+
+}
+
+func (v *Rule) isValidPort(s int, fldPath *field.Path) (bool, error) {
+
+	return v.isValidPortRange(s, s, fldPath)
+
+}
+
+// isValidPortRange checks the ports from start to end. Numeric operators
+// apply to both ends of the range, In requires every port of the range to be
+// in the rule values and NotIn none of them.
+func (v *Rule) isValidPortRange(start, end int, fldPath *field.Path) (bool, error) {
+
+	actual := strconv.Itoa(start)
+	if end != start {
+		actual = fmt.Sprintf("%d-%d", start, end)
+	}
+
+	switch v.Operator {
+
+	case OpIn:
+
+		ok := end-start < len(v.Values)
+		for p := start; ok && p <= end; p++ {
+			ok = contains(v.Values, strconv.Itoa(p))
+		}
+
+		if !ok {
+			return false, v.violation(fldPath, actual, fmt.Sprintf(
+				"error InvalidPortNumber: port number must be %s %v", v.Operator, v.expected()))
+		}
+
+		return true, nil
+
+	case OpNotIn:
+
+		for _, i := range v.Values {
+			if p, err := strconv.Atoi(i); err == nil && start <= p && p <= end {
+				return false, v.violation(fldPath, actual, fmt.Sprintf(
+					"error InvalidPortNumber: port number must be %s %v", v.Operator, v.expected()))
+			}
+		}
+
+		return true, nil
+
+	}
+
+	if ok, err := v.check(start, fldPath, "InvalidPortNumber", "port number"); !ok {
+		return false, err
+	}
+
+	return v.check(end, fldPath, "InvalidPortNumber", "port number")
+
+}
+
+func (v *Rule) isValidLabelCount(labels map[string]string, fldPath *field.Path) (bool, error) {
+
+	return v.check(len(labels), fldPath, "InvalidLabelCount", "the number of labels")
+
+}
+
+// isValidLabelValues checks the label named by the rule Key. Exists and
+// DoesNotExist check the key presence, NotIn accepts a missing key and every
+// other operator requires the key to be present with a matching value.
+func (v *Rule) isValidLabelValues(labels map[string]string, fldPath *field.Path) (bool, error) {
+
+	fldPath = fldPath.Key(v.Key)
+	value, found := labels[v.Key]
+
+	switch v.Operator {
+
+	case OpExists, OpDoesNotExist:
+
+		ok, err := operatorExec(labels, v.operand(), v.Operator)
+		if err != nil {
+			return false, v.violation(fldPath, value, err.Error())
+		}
+
+		if !ok {
+			return false, v.violation(fldPath, value, fmt.Sprintf(
+				"error InvalidLabelValue: label %s must satisfy %s", v.Key, v.Operator))
+		}
+
+		return true, nil
+
+	case OpNotIn:
+
+	default:
+
+		if !found {
+			return false, v.violation(fldPath, "", fmt.Sprintf(
+				"error InvalidLabelValue: label %s is required", v.Key))
+		}
+
+	}
+
+	return v.check(value, fldPath, "InvalidLabelValue", "label "+v.Key)
+
+}
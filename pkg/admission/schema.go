@@ -0,0 +1,379 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ruleSet is implemented by the validators holding a list of rules, it
+// returns the rules supported by the validator.
+type ruleSet interface {
+	supportedRules() []RuleName
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	ruleType        = reflect.TypeOf(Rule{})
+	ruleSetType     = reflect.TypeOf((*ruleSet)(nil)).Elem()
+)
+
+// enums lists the values allowed for the config types with a fixed set of
+// values
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(PolicyType("")): {
+		string(PolicyTypeIngress), string(PolicyTypeEgress),
+	},
+	reflect.TypeOf(Enforcement("")): {
+		string(EnforcementEnforce), string(EnforcementWarn), string(EnforcementAudit),
+	},
+}
+
+// checkConfig strictly validates config content b against the schema of
+// NetworkAdmissionValidator. Unknown fields, unknown rule names and
+// operators, rules used where they are not supported, operators that don't
+// apply to a rule and invalid rule values are reported with their line and
+// column in b.
+func checkConfig(b []byte) error {
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	if len(doc.Content) == 0 {
+		return fmt.Errorf("config is empty")
+	}
+
+	c := &schemaChecker{}
+	c.check(doc.Content[0], reflect.TypeOf(NetworkAdmissionValidator{}), "", nil)
+
+	return utilerrors.NewAggregate(c.errs)
+}
+
+type schemaChecker struct {
+	errs []error
+}
+
+func (c *schemaChecker) errorf(n *yamlv3.Node, path, format string, a ...interface{}) {
+
+	if path == "" {
+		path = "config"
+	}
+
+	c.errs = append(c.errs, fmt.Errorf("line %d, column %d: %s: %s",
+		n.Line, n.Column, path, fmt.Sprintf(format, a...)))
+}
+
+// check validates node n against type t. rules holds the rules supported by
+// the closest ruleSet enclosing n.
+func (c *schemaChecker) check(n *yamlv3.Node, t reflect.Type, path string, rules []RuleName) {
+
+	if n.Kind == yamlv3.AliasNode {
+		n = n.Alias
+	}
+
+	if n.Kind == yamlv3.ScalarNode && n.ShortTag() == "!!null" {
+		return
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if reflect.PtrTo(t).Implements(ruleSetType) {
+		rules = reflect.New(t).Interface().(ruleSet).supportedRules()
+	}
+
+	switch {
+
+	case t == ruleType:
+
+		if c.expect(n, yamlv3.MappingNode, path) {
+			c.checkFields(n, t, path, rules)
+			c.checkRule(n, path, rules)
+		}
+
+	case enums[t] != nil:
+
+		if c.expect(n, yamlv3.ScalarNode, path) && !contains(enums[t], n.Value) {
+			c.errorf(n, path, "unknown value %q, expected one of [%s]", n.Value, strings.Join(enums[t], ", "))
+		}
+
+	case reflect.PtrTo(t).Implements(jsonUnmarshaler):
+
+		c.expect(n, yamlv3.ScalarNode, path)
+
+	case t.Kind() == reflect.Struct:
+
+		if c.expect(n, yamlv3.MappingNode, path) {
+			c.checkFields(n, t, path, rules)
+		}
+
+	case t.Kind() == reflect.Slice:
+
+		if c.expect(n, yamlv3.SequenceNode, path) {
+			for i, e := range n.Content {
+				c.check(e, t.Elem(), fmt.Sprintf("%s[%d]", path, i), rules)
+			}
+		}
+
+	case t.Kind() == reflect.Map:
+
+		if c.expect(n, yamlv3.MappingNode, path) {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				c.check(n.Content[i+1], t.Elem(), fmt.Sprintf("%s[%s]", path, n.Content[i].Value), rules)
+			}
+		}
+
+	case t.Kind() == reflect.Interface:
+
+	default:
+
+		if c.expect(n, yamlv3.ScalarNode, path) && t.Kind() == reflect.String && n.ShortTag() != "!!str" {
+			c.errorf(n, path, "expected a string, got %s, quote the value", n.Value)
+		}
+
+	}
+}
+
+func (c *schemaChecker) expect(n *yamlv3.Node, k yamlv3.Kind, path string) bool {
+
+	if n.Kind == k {
+		return true
+	}
+
+	kinds := map[yamlv3.Kind]string{
+		yamlv3.MappingNode:  "a mapping",
+		yamlv3.SequenceNode: "a list",
+		yamlv3.ScalarNode:   "a value",
+	}
+
+	c.errorf(n, path, "expected %s", kinds[k])
+	return false
+}
+
+// checkFields validates every key of mapping n against the json fields of
+// struct t.
+func (c *schemaChecker) checkFields(n *yamlv3.Node, t reflect.Type, path string, rules []RuleName) {
+
+	fields := jsonFields(t)
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+
+		k := n.Content[i]
+		p := k.Value
+		if path != "" {
+			p = path + "." + k.Value
+		}
+
+		f, ok := fields[k.Value]
+		if !ok {
+			c.errorf(k, path, "unknown field %q", k.Value)
+			continue
+		}
+
+		c.check(n.Content[i+1], f, p, rules)
+	}
+}
+
+// jsonFields maps the json names of the fields of struct t to their type.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+
+	fields := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			for k, v := range jsonFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields[name] = f.Type
+	}
+
+	return fields
+}
+
+// checkRule validates the rule name, operator and values of rule n.
+func (c *schemaChecker) checkRule(n *yamlv3.Node, path string, rules []RuleName) {
+
+	name := mappingValue(n, "name")
+	if name == nil || name.Value == "" {
+		c.errorf(n, path, "rule name is required")
+		return
+	}
+
+	r := RuleName(name.Value)
+	operators, ok := ruleOperators[r]
+	if !ok {
+		c.errorf(name, path+".name", "unknown rule %q", name.Value)
+		return
+	}
+
+	if !containsRule(rules, r) {
+		c.errorf(name, path+".name", "rule %q is not supported here", name.Value)
+		return
+	}
+
+	operator := mappingValue(n, "operator")
+	if operator == nil || operator.Value == "" {
+		c.errorf(n, path, "rule operator is required")
+		return
+	}
+
+	o := Operator(operator.Value)
+	if !containsOperator(allOperators, o) {
+		c.errorf(operator, path+".operator", "unknown operator %q", operator.Value)
+		return
+	}
+
+	if !containsOperator(operators, o) {
+		c.errorf(operator, path+".operator", "operator %q can't be used with rule %q", operator.Value, name.Value)
+		return
+	}
+
+	if containsRule(keyedRules, r) {
+		if key := mappingValue(n, "key"); key == nil || key.Value == "" {
+			c.errorf(n, path, "rule %q requires a key", name.Value)
+		} else if r == Subjects && !strings.HasPrefix(key.Value, "Role:") && !strings.HasPrefix(key.Value, "ClusterRole:") {
+			c.errorf(key, path+".key", "rule %q requires a key Role:name or ClusterRole:name, got %q", name.Value, key.Value)
+		}
+	}
+
+	value := mappingValue(n, "value")
+
+	switch o {
+
+	case OpIn, OpNotIn:
+
+		values := mappingValue(n, "values")
+		if values == nil || len(values.Content) == 0 {
+			c.errorf(n, path, "operator %q requires values", operator.Value)
+			break
+		}
+
+		if containsRule(cidrRules, r) {
+			for i, e := range values.Content {
+				if _, _, err := net.ParseCIDR(e.Value); err != nil {
+					c.errorf(e, fmt.Sprintf("%s.values[%d]", path, i), "rule %q requires CIDRs, got %q", name.Value, e.Value)
+				}
+			}
+		}
+
+		if containsRule(familyRules, r) {
+			for i, e := range values.Content {
+				if e.Value != IPv4 && e.Value != IPv6 {
+					c.errorf(e, fmt.Sprintf("%s.values[%d]", path, i), "rule %q requires %q or %q, got %q", name.Value, IPv4, IPv6, e.Value)
+				}
+			}
+		}
+
+		if containsRule(portRules, r) {
+			for i, e := range values.Content {
+				if p, err := strconv.Atoi(e.Value); err != nil || p < 1 || p > 65535 {
+					c.errorf(e, fmt.Sprintf("%s.values[%d]", path, i), "rule %q requires port numbers, got %q", name.Value, e.Value)
+				}
+			}
+		}
+
+		if r == Protocol {
+			for i, e := range values.Content {
+				if !contains(protocols, e.Value) {
+					c.errorf(e, fmt.Sprintf("%s.values[%d]", path, i), "rule %q requires one of [%s], got %q", name.Value, strings.Join(protocols, ", "), e.Value)
+				}
+			}
+		}
+
+		if containsRule(booleanRules, r) {
+			for i, e := range values.Content {
+				if e.Value != "true" && e.Value != "false" {
+					c.errorf(e, fmt.Sprintf("%s.values[%d]", path, i), "rule %q requires \"true\" or \"false\", got %q", name.Value, e.Value)
+				}
+			}
+		}
+
+	case OpExists, OpDoesNotExist:
+
+	case OpMatches:
+
+		if value == nil {
+			c.errorf(n, path, "operator %q requires a value", operator.Value)
+		} else if _, err := compilePattern(value.Value); err != nil {
+			c.errorf(value, path+".value", "invalid pattern %q: %v", value.Value, err)
+		}
+
+	default:
+
+		if containsRule(quantityRules, r) {
+			if value == nil {
+				c.errorf(n, path, "rule %q requires a quantity", name.Value)
+			} else if _, err := resource.ParseQuantity(value.Value); err != nil || value.Kind != yamlv3.ScalarNode {
+				c.errorf(value, path+".value", "rule %q requires a quantity, got %q", name.Value, value.Value)
+			}
+			break
+		}
+
+		if value == nil {
+			c.errorf(n, path, "rule %q requires a numeric value", name.Value)
+		} else if _, err := strconv.Atoi(value.Value); err != nil || value.Kind != yamlv3.ScalarNode {
+			c.errorf(value, path+".value", "rule %q requires a numeric value, got %q", name.Value, value.Value)
+		}
+
+	}
+}
+
+// mappingValue returns the value of key in mapping n or nil when it is missing.
+func mappingValue(n *yamlv3.Node, key string) *yamlv3.Node {
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func containsRule(l []RuleName, r RuleName) bool {
+
+	for _, i := range l {
+		if i == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsOperator(l []Operator, o Operator) bool {
+
+	for _, i := range l {
+		if i == o {
+			return true
+		}
+	}
+
+	return false
+}
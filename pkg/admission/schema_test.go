@@ -0,0 +1,338 @@
+package admission
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCheckConfigFiles(t *testing.T) {
+
+	tests := []string{
+		"../../files/validator.yaml",
+		"../../examples/config.yaml",
+	}
+
+	for _, i := range tests {
+
+		b, err := ioutil.ReadFile(i)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+
+		if err := checkConfig(b); err != nil {
+			t.Errorf("%s: unexpected error %v", i, err)
+		}
+
+	}
+
+}
+
+func TestCheckConfig(t *testing.T) {
+
+	tests := []struct {
+		config   string
+		expected string
+	}{
+		{
+			`networkPolicyValidator:
+  podSelecter:
+    matchLabels: {}
+`,
+			`line 2, column 3: networkPolicyValidator: unknown field "podSelecter"`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    from:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "MaskBitSize"
+            operator: "Ge"
+            value: 29
+`,
+			`line 7, column 19: networkPolicyValidator.ingress.from.ipBlock.cidr.rules[0].name: unknown rule "MaskBitSize"`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    from:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "MaskBitsSize"
+            operator: "In"
+            values: ["29"]
+`,
+			`line 8, column 23: networkPolicyValidator.ingress.from.ipBlock.cidr.rules[0].operator: operator "In" can't be used with rule "MaskBitsSize"`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    from:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "MaskBitsSize"
+            operator: "Ge"
+            value: "twenty"
+`,
+			`line 9, column 20: networkPolicyValidator.ingress.from.ipBlock.cidr.rules[0].value: rule "MaskBitsSize" requires a numeric value, got "twenty"`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    from:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "LabelCount"
+            operator: "Ge"
+            value: 1
+`,
+			`line 7, column 19: networkPolicyValidator.ingress.from.ipBlock.cidr.rules[0].name: rule "LabelCount" is not supported here`,
+		},
+		{
+			`networkPolicyValidator:
+  podSelector:
+    matchLabels:
+      rules:
+      - name: "LabelCount"
+        operator: "Greater"
+        value: 1
+`,
+			`line 6, column 19: networkPolicyValidator.podSelector.matchLabels.rules[0].operator: unknown operator "Greater"`,
+		},
+		{
+			`networkPolicyValidator:
+  podSelector:
+    matchLabels:
+      rules:
+      - name: "LabelValues"
+        operator: "In"
+        key: "app"
+`,
+			`line 5, column 9: networkPolicyValidator.podSelector.matchLabels.rules[0]: operator "In" requires values`,
+		},
+		{
+			`networkPolicyValidator:
+  podSelector:
+    matchLabels:
+      rules:
+      - name: "LabelValues"
+        operator: "Exists"
+`,
+			`line 5, column 9: networkPolicyValidator.podSelector.matchLabels.rules[0]: rule "LabelValues" requires a key`,
+		},
+		{
+			`networkPolicyValidator:
+  allowedPolicyTypes:
+  - Ingres
+`,
+			`line 3, column 5: networkPolicyValidator.allowedPolicyTypes[0]: unknown value "Ingres", expected one of [Ingress, Egress]`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    ports: []
+`,
+			`line 3, column 12: networkPolicyValidator.ingress.ports: expected a mapping`,
+		},
+		{
+			`podValidator:
+  containers:
+    securityContext:
+      rules:
+      - name: "Privileged"
+        operator: "In"
+        values: ["no"]
+`,
+			`line 7, column 18: podValidator.containers.securityContext.rules[0].values[0]: rule "Privileged" requires "true" or "false", got "no"`,
+		},
+		{
+			`podValidator:
+  rules:
+  - name: "HostNetwork"
+    operator: "In"
+    values: [false]
+`,
+			`line 5, column 14: podValidator.rules[0].values[0]: expected a string, got false, quote the value`,
+		},
+		{
+			`podValidator:
+  volumes:
+    rules:
+    - name: "ImageTag"
+      operator: "NotIn"
+      values: ["latest"]
+`,
+			`line 4, column 13: podValidator.volumes.rules[0].name: rule "ImageTag" is not supported here`,
+		},
+		{
+			`serviceValidator:
+  externalIPs:
+    rules:
+    - name: "WithinCIDRs"
+      operator: "In"
+      values: ["10.0.0.0/33"]
+`,
+			`line 6, column 16: serviceValidator.externalIPs.rules[0].values[0]: rule "WithinCIDRs" requires CIDRs, got "10.0.0.0/33"`,
+		},
+		{
+			`networkPolicyValidator:
+  egress:
+    to:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "NotOverlappingCIDRs"
+            operator: "NotIn"
+            values: ["169.254.0.0/16"]
+`,
+			`line 8, column 23: networkPolicyValidator.egress.to.ipBlock.cidr.rules[0].operator: operator "NotIn" can't be used with rule "NotOverlappingCIDRs"`,
+		},
+		{
+			`networkPolicyValidator:
+  egress:
+    to:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "NotOverlappingCIDRs"
+            operator: "In"
+            values: ["169.254.169.254"]
+`,
+			`line 9, column 22: networkPolicyValidator.egress.to.ipBlock.cidr.rules[0].values[0]: rule "NotOverlappingCIDRs" requires CIDRs, got "169.254.169.254"`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    from:
+      ipBlock:
+        cidr:
+          rules:
+          - name: "AddressFamily"
+            operator: "NotIn"
+            values: ["IPv6", "ipv4"]
+`,
+			`line 9, column 30: networkPolicyValidator.ingress.from.ipBlock.cidr.rules[0].values[1]: rule "AddressFamily" requires "IPv4" or "IPv6", got "ipv4"`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    from:
+      ipBlock:
+        rules:
+        - name: "ExceptWithinCIDR"
+          operator: "In"
+          values: ["yes"]
+`,
+			`line 8, column 20: networkPolicyValidator.ingress.from.ipBlock.rules[0].values[0]: rule "ExceptWithinCIDR" requires "true" or "false", got "yes"`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    from:
+      ipBlock:
+        rules:
+        - name: "MaskBitsSize"
+          operator: "Ge"
+          value: 24
+`,
+			`line 6, column 17: networkPolicyValidator.ingress.from.ipBlock.rules[0].name: rule "MaskBitsSize" is not supported here`,
+		},
+		{
+			`networkPolicyValidator:
+  ingress:
+    ports:
+      rules:
+      - name: "PortNumber"
+        operator: "In"
+        values: ["80", "http"]
+`,
+			`line 7, column 24: networkPolicyValidator.ingress.ports.rules[0].values[1]: rule "PortNumber" requires port numbers, got "http"`,
+		},
+		{
+			`networkPolicyValidator:
+  egress:
+    ports:
+      rules:
+      - name: "Protocol"
+        operator: "NotIn"
+        values: ["ICMP"]
+`,
+			`line 7, column 18: networkPolicyValidator.egress.ports.rules[0].values[0]: rule "Protocol" requires one of [TCP, UDP, SCTP], got "ICMP"`,
+		},
+		{
+			`resourcesValidator:
+  cpu:
+    rules:
+    - name: "ResourceLimit"
+      operator: "Le"
+      value: "two"
+`,
+			`line 6, column 14: resourcesValidator.cpu.rules[0].value: rule "ResourceLimit" requires a quantity, got "two"`,
+		},
+		{
+			`rbacValidator:
+  bindings:
+    rules:
+    - name: "Subjects"
+      operator: "In"
+      values: ["Group:system:masters"]
+`,
+			`line 4, column 7: rbacValidator.bindings.rules[0]: rule "Subjects" requires a key`,
+		},
+		{
+			`rbacValidator:
+  bindings:
+    rules:
+    - name: "Subjects"
+      operator: "In"
+      key: "cluster-admin"
+      values: ["Group:system:masters"]
+`,
+			`line 6, column 12: rbacValidator.bindings.rules[0].key: rule "Subjects" requires a key Role:name or ClusterRole:name, got "cluster-admin"`,
+		},
+		{
+			``,
+			`config is empty`,
+		},
+	}
+
+	for _, i := range tests {
+
+		err := checkConfig([]byte(i.config))
+		if err == nil {
+			t.Errorf("expected error %q", i.expected)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), i.expected) {
+			t.Errorf("error was %q and expected is %q", err, i.expected)
+		}
+
+	}
+
+}
+
+func TestCheckConfigReportsEveryError(t *testing.T) {
+
+	config := `networkPolicyValidator:
+  podSelecter: {}
+  egres: {}
+`
+
+	err := checkConfig([]byte(config))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	for _, i := range []string{`unknown field "podSelecter"`, `unknown field "egres"`} {
+		if !strings.Contains(err.Error(), i) {
+			t.Errorf("error was %q and expected to contain %q", err, i)
+		}
+	}
+
+}
@@ -0,0 +1,182 @@
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// selectorStep is a step of a field selector: a field name, a list index,
+// a map key or every element of a list or map.
+type selectorStep struct {
+	field string
+	key   string
+	index int
+	all   bool
+}
+
+// selectorValue is a value selected in an object along with its path
+type selectorValue struct {
+	value   interface{}
+	fldPath *field.Path
+}
+
+// parseSelector parses a JSONPath-like field selector, e.g.
+// spec.template.spec.containers[*].image or metadata.labels['app.kubernetes.io/name'].
+// Fields are separated by dots, [N] selects a list element, ['key'] a map
+// key and [*] every element of a list or map.
+func parseSelector(s string) ([]selectorStep, error) {
+
+	var steps []selectorStep
+	i := 0
+
+	for {
+
+		// a field name is expected first and after every dot
+		end := strings.IndexAny(s[i:], ".[")
+		if end < 0 {
+			end = len(s) - i
+		}
+
+		if end == 0 {
+			return nil, fmt.Errorf("expected a field name at position %d of %q", i, s)
+		}
+
+		steps = append(steps, selectorStep{field: s[i : i+end]})
+		i += end
+
+		for i < len(s) && s[i] == '[' {
+
+			end := strings.Index(s[i:], "]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in %q", s)
+			}
+
+			step, err := parseBracket(s[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("%v in %q", err, s)
+			}
+
+			steps = append(steps, step)
+			i += end + 1
+		}
+
+		if i == len(s) {
+			return steps, nil
+		}
+
+		if s[i] != '.' {
+			return nil, fmt.Errorf("unexpected %q at position %d of %q", s[i], i, s)
+		}
+		i++
+	}
+}
+
+func parseBracket(s string) (selectorStep, error) {
+
+	if s == "*" {
+		return selectorStep{all: true}, nil
+	}
+
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return selectorStep{key: s[1 : len(s)-1]}, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		return selectorStep{}, fmt.Errorf("invalid index %q", s)
+	}
+
+	return selectorStep{index: i}, nil
+}
+
+// selectValues returns the values selected by steps in obj, the content of
+// an unstructured object. Missing fields select no value.
+func selectValues(obj interface{}, steps []selectorStep) []selectorValue {
+
+	values := []selectorValue{{value: obj}}
+
+	for _, s := range steps {
+
+		var next []selectorValue
+
+		for _, v := range values {
+
+			switch o := v.value.(type) {
+
+			case map[string]interface{}:
+
+				switch {
+
+				case s.all:
+
+					keys := make([]string, 0, len(o))
+					for k := range o {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+
+					for _, k := range keys {
+						next = append(next, selectorValue{o[k], v.fldPath.Key(k)})
+					}
+
+				case s.field != "":
+
+					if e, ok := o[s.field]; ok {
+						next = append(next, selectorValue{e, v.fldPath.Child(s.field)})
+					}
+
+				case s.key != "":
+
+					if e, ok := o[s.key]; ok {
+						next = append(next, selectorValue{e, v.fldPath.Key(s.key)})
+					}
+
+				}
+
+			case []interface{}:
+
+				switch {
+
+				case s.all:
+
+					for k, e := range o {
+						next = append(next, selectorValue{e, v.fldPath.Index(k)})
+					}
+
+				case s.field == "" && s.key == "" && s.index < len(o):
+
+					next = append(next, selectorValue{o[s.index], v.fldPath.Index(s.index)})
+
+				}
+
+			}
+		}
+
+		values = next
+	}
+
+	return values
+}
+
+// scalarString renders a scalar value of an unstructured object
+func scalarString(v interface{}) (string, bool) {
+
+	switch s := v.(type) {
+
+	case string:
+		return s, true
+	case bool:
+		return strconv.FormatBool(s), true
+	case int64:
+		return strconv.FormatInt(s, 10), true
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64), true
+
+	}
+
+	return "", false
+}
@@ -0,0 +1,102 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+
+	tests := []struct {
+		selector string
+		steps    int
+		valid    bool
+	}{
+		{"spec", 1, true},
+		{"spec.template.spec.containers[*].image", 6, true},
+		{"spec.containers[0].ports[*].containerPort", 6, true},
+		{"metadata.labels['app.kubernetes.io/name']", 3, true},
+		{`metadata.annotations["example.com/owner"]`, 3, true},
+		{"spec.rules[*][*]", 4, true},
+		{"", 0, false},
+		{".spec", 0, false},
+		{"spec.", 0, false},
+		{"spec..containers", 0, false},
+		{"spec.containers[", 0, false},
+		{"spec.containers[x]", 0, false},
+		{"spec.containers[-1]", 0, false},
+		{"spec.containers[*]image", 0, false},
+	}
+
+	for _, i := range tests {
+
+		steps, err := parseSelector(i.selector)
+
+		if (err == nil) != i.valid {
+			t.Errorf("%q: error was %v and expected valid is %v", i.selector, err, i.valid)
+			continue
+		}
+
+		if len(steps) != i.steps {
+			t.Errorf("%q: steps were %v and expected are %d", i.selector, steps, i.steps)
+		}
+
+	}
+
+}
+
+func TestSelectValues(t *testing.T) {
+
+	obj := `{
+		"metadata": { "labels": { "app.kubernetes.io/name": "shop", "tier": "web" } },
+		"spec": {
+			"replicas": 3,
+			"template": { "spec": { "containers": [
+				{ "name": "app", "image": "gcr.io/shop/app:v1" },
+				{ "name": "proxy", "image": "envoy:v1" }
+			] } }
+		}
+	}`
+
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{"spec.template.spec.containers[*].image", []string{"spec.template.spec.containers[0].image", "spec.template.spec.containers[1].image"}},
+		{"spec.template.spec.containers[1].name", []string{"spec.template.spec.containers[1].name"}},
+		{"spec.template.spec.containers[2].name", nil},
+		{"metadata.labels['app.kubernetes.io/name']", []string{"metadata.labels[app.kubernetes.io/name]"}},
+		{"metadata.labels[*]", []string{"metadata.labels[app.kubernetes.io/name]", "metadata.labels[tier]"}},
+		{"spec.replicas", []string{"spec.replicas"}},
+		{"spec.missing.field", nil},
+		{"spec.replicas.field", nil},
+	}
+
+	var o map[string]interface{}
+	if err := json.Unmarshal([]byte(obj), &o); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	for _, i := range tests {
+
+		steps, err := parseSelector(i.selector)
+		if err != nil {
+			t.Fatalf("%q: error %v", i.selector, err)
+		}
+
+		values := selectValues(o, steps)
+
+		if len(values) != len(i.expected) {
+			t.Errorf("%q: values were %v and expected are %v", i.selector, values, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if values[k].fldPath.String() != e {
+				t.Errorf("%q: value path was %s and expected is %s", i.selector, values[k].fldPath, e)
+			}
+		}
+
+	}
+
+}
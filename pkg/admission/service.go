@@ -0,0 +1,242 @@
+package admission
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateService checks the type, node ports, external IPs and load
+// balancer source ranges of service s with the service rules of its profile.
+func (v *NetworkAdmissionValidator) ValidateService(s *corev1.Service, namespaceLabels map[string]string) ViolationList {
+
+	validators := v.validators(s.Namespace, namespaceLabels)
+
+	if ok, err := validators.ServiceValidator.isValid(&s.Spec, s.Annotations, field.NewPath("spec")); !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement)
+	}
+
+	return nil
+
+}
+
+// ServiceValidator provides the specification of a Service
+type ServiceValidator struct {
+	Rules                    []Rule                   `json:"rules,omitempty"`
+	NodePort                 NodePort                 `json:"nodePort,omitempty"`
+	ExternalIPs              ExternalIPs              `json:"externalIPs,omitempty"`
+	LoadBalancerSourceRanges LoadBalancerSourceRanges `json:"loadBalancerSourceRanges,omitempty"`
+}
+
+// supported rules to check
+// ServiceType
+func (v *ServiceValidator) supportedRules() []RuleName {
+	return []RuleName{ServiceType}
+}
+
+func (v *ServiceValidator) isValid(p *corev1.ServiceSpec, annotations map[string]string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	serviceType := p.Type
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ServiceType:
+
+			if ok, err := r.check(string(serviceType), fldPath.Child("type"), "InvalidServiceType", "service type"); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	if ok, err := v.NodePort.isValid(p.Ports, fldPath.Child("ports")); !ok {
+		errs = append(errs, err)
+	}
+
+	if ok, err := v.ExternalIPs.isValid(p.ExternalIPs, fldPath.Child("externalIPs")); !ok {
+		errs = append(errs, err)
+	}
+
+	// source ranges only apply to load balancers
+	if serviceType == corev1.ServiceTypeLoadBalancer {
+
+		ranges, rangesPath := loadBalancerSourceRanges(p, annotations, fldPath)
+
+		if ok, err := v.LoadBalancerSourceRanges.isValid(ranges, rangesPath); !ok {
+			errs = append(errs, err)
+		}
+
+	}
+
+	return aggregate(errs)
+
+}
+
+// NodePort checks the node ports of a Service, ports without a node port
+// are skipped.
+type NodePort struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// PortNumber
+func (v *NodePort) supportedRules() []RuleName {
+	return []RuleName{PortNumber}
+}
+
+func (v *NodePort) isValid(p []corev1.ServicePort, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		for k, i := range p {
+
+			if i.NodePort == 0 {
+				continue
+			}
+
+			if ok, err := r.isValidPort(int(i.NodePort), fldPath.Index(k).Child("nodePort")); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// ExternalIPs checks the external IPs of a Service
+type ExternalIPs struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// ListSize
+// WithinCIDRs
+// AddressFamily
+func (v *ExternalIPs) supportedRules() []RuleName {
+	return []RuleName{ListSize, WithinCIDRs, AddressFamily}
+}
+
+func (v *ExternalIPs) isValid(p []string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ListSize:
+
+			if ok, err := r.isValidListSize(len(p), fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case WithinCIDRs:
+
+			if ok, err := r.isWithinCIDRsList(p, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case AddressFamily:
+
+			if ok, err := r.isValidAddressFamilyList(p, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
+
+// loadBalancerSourceRanges returns the source ranges of a LoadBalancer
+// Service along with their field path. Cloud providers read the
+// service.beta.kubernetes.io/load-balancer-source-ranges annotation when
+// spec.loadBalancerSourceRanges is empty.
+func loadBalancerSourceRanges(p *corev1.ServiceSpec, annotations map[string]string, fldPath *field.Path) ([]string, *field.Path) {
+
+	a := strings.TrimSpace(annotations[corev1.AnnotationLoadBalancerSourceRangesKey])
+	if len(p.LoadBalancerSourceRanges) > 0 || a == "" {
+		return p.LoadBalancerSourceRanges, fldPath.Child("loadBalancerSourceRanges")
+	}
+
+	var ranges []string
+	for _, i := range strings.Split(a, ",") {
+		ranges = append(ranges, strings.TrimSpace(i))
+	}
+
+	return ranges, field.NewPath("metadata", "annotations").Key(corev1.AnnotationLoadBalancerSourceRangesKey)
+}
+
+// LoadBalancerSourceRanges checks the source ranges of LoadBalancer Services
+type LoadBalancerSourceRanges struct {
+	Rules []Rule `json:"rules"`
+}
+
+// supported rules to check
+// ListSize
+// MaskBitsSize
+// MaskBitsSizeV4
+// MaskBitsSizeV6
+// AddressFamily
+func (v *LoadBalancerSourceRanges) supportedRules() []RuleName {
+	return []RuleName{ListSize, MaskBitsSize, MaskBitsSizeV4, MaskBitsSizeV6, AddressFamily}
+}
+
+func (v *LoadBalancerSourceRanges) isValid(p []string, fldPath *field.Path) (bool, error) {
+
+	var errs []error
+
+	for _, r := range v.Rules {
+
+		switch r.Name {
+
+		case ListSize:
+
+			if ok, err := r.isValidListSize(len(p), fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case MaskBitsSize:
+
+			if ok, err := r.isValidMaskList(p, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case MaskBitsSizeV4:
+
+			if ok, err := r.isValidFamilyMaskList(p, IPv4, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case MaskBitsSizeV6:
+
+			if ok, err := r.isValidFamilyMaskList(p, IPv6, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		case AddressFamily:
+
+			if ok, err := r.isValidAddressFamilyList(p, fldPath); !ok {
+				errs = append(errs, err)
+			}
+
+		}
+	}
+
+	return aggregate(errs)
+
+}
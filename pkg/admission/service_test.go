@@ -0,0 +1,259 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestExternalIPs(t *testing.T) {
+
+	tests := []struct {
+		rules       string
+		expected    bool
+		externalIPs []string
+	}{
+		{`{ "rules": [
+			{
+				"name": "ListSize",
+				"operator": "Le",
+				"value": 0
+			}
+		]}`,
+			false,
+			[]string{"203.0.113.10"},
+		},
+		{`{ "rules": [
+			{
+				"name": "ListSize",
+				"operator": "Le",
+				"value": 0
+			}
+		]}`,
+			true,
+			nil,
+		},
+		{`{ "rules": [
+			{
+				"name": "WithinCIDRs",
+				"operator": "In",
+				"values": ["203.0.113.0/24", "2001:db8::/32"]
+			}
+		]}`,
+			true,
+			[]string{"203.0.113.10", "2001:db8::1"},
+		},
+		{`{ "rules": [
+			{
+				"name": "WithinCIDRs",
+				"operator": "In",
+				"values": ["203.0.113.0/24"]
+			}
+		]}`,
+			false,
+			[]string{"203.0.113.10", "198.51.100.10"},
+		},
+		{`{ "rules": [
+			{
+				"name": "WithinCIDRs",
+				"operator": "In",
+				"values": ["203.0.113.0/24"]
+			}
+		]}`,
+			false,
+			[]string{"not-an-ip"},
+		},
+		{`{ "rules": [
+			{
+				"name": "WithinCIDRs",
+				"operator": "NotIn",
+				"values": ["10.0.0.0/8", "169.254.0.0/16"]
+			}
+		]}`,
+			false,
+			[]string{"203.0.113.10", "169.254.169.254"},
+		},
+		{`{ "rules": [
+			{
+				"name": "WithinCIDRs",
+				"operator": "NotIn",
+				"values": ["10.0.0.0/8"]
+			}
+		]}`,
+			true,
+			[]string{"203.0.113.10"},
+		},
+		{`{ "rules": [
+			{
+				"name": "AddressFamily",
+				"operator": "In",
+				"values": ["IPv4"]
+			}
+		]}`,
+			false,
+			[]string{"203.0.113.10", "2001:db8::1"},
+		},
+		{`{ "rules": [
+			{
+				"name": "AddressFamily",
+				"operator": "NotIn",
+				"values": ["IPv4"]
+			}
+		]}`,
+			true,
+			[]string{"2001:db8::1"},
+		},
+	}
+
+	for _, i := range tests {
+
+		a := ExternalIPs{}
+		if err := json.Unmarshal([]byte(i.rules), &a); err != nil {
+			t.Errorf("error %v", err)
+		}
+
+		result, err := a.isValid(i.externalIPs, field.NewPath("externalIPs"))
+
+		if result != i.expected {
+			t.Errorf("%v: result was %v and expected is %v: %v", i.externalIPs, result, i.expected, err)
+		}
+
+	}
+
+}
+
+func TestContainsNetwork(t *testing.T) {
+
+	tests := []struct {
+		a        string
+		b        string
+		expected bool
+	}{
+		{"10.0.0.0/8", "10.1.0.0/16", true},
+		{"10.0.0.0/8", "10.1.2.3", true},
+		{"10.0.0.0/16", "10.0.0.0/8", false},
+		{"10.0.0.0/8", "11.0.0.0/16", false},
+		{"::/0", "10.0.0.1", false},
+		{"2001:db8::/32", "2001:db8:1::/48", true},
+	}
+
+	for _, i := range tests {
+
+		a, err := parseNetwork(i.a)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+
+		b, err := parseNetwork(i.b)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+
+		if result := containsNetwork(a, b); result != i.expected {
+			t.Errorf("%s in %s: result was %v and expected is %v", i.b, i.a, result, i.expected)
+		}
+
+	}
+
+}
+
+func TestValidateService(t *testing.T) {
+
+	config := `
+serviceValidator:
+  rules:
+  - name: "ServiceType"
+    operator: "In"
+    values: ["ClusterIP", "LoadBalancer"]
+  nodePort:
+    rules:
+    - name: "PortNumber"
+      operator: "Ge"
+      value: 30000
+    - name: "PortNumber"
+      operator: "Le"
+      value: 30100
+  externalIPs:
+    rules:
+    - name: "ListSize"
+      operator: "Le"
+      value: 0
+  loadBalancerSourceRanges:
+    rules:
+    - name: "ListSize"
+      operator: "Ge"
+      value: 1
+    - name: "MaskBitsSize"
+      operator: "Ge"
+      value: 16
+profiles:
+- name: edge
+  namespaces:
+  - edge
+  serviceValidator:
+    rules:
+    - name: "ServiceType"
+      operator: "In"
+      values: ["ClusterIP", "NodePort"]
+    nodePort:
+      rules:
+      - name: "PortNumber"
+        operator: "Ge"
+        value: 30000
+`
+
+	v, err := parseAdmissionValidator([]byte(config))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	tests := []struct {
+		namespace   string
+		spec        string
+		annotations map[string]string
+		expected    []string
+	}{
+		{"default", `{ "ports": [ { "port": 80 } ] }`, nil, nil},
+		{"default", `{ "type": "NodePort", "ports": [ { "port": 80, "nodePort": 32000 } ] }`, nil,
+			[]string{"spec.type", "spec.ports[0].nodePort"}},
+		{"default", `{ "type": "LoadBalancer", "ports": [ { "port": 80, "nodePort": 30080 } ] }`, nil,
+			[]string{"spec.loadBalancerSourceRanges"}},
+		{"default", `{ "type": "LoadBalancer", "ports": [ { "port": 80 } ], "loadBalancerSourceRanges": ["10.0.0.0/16", "0.0.0.0/0"] }`, nil,
+			[]string{"spec.loadBalancerSourceRanges[1]"}},
+		{"default", `{ "ports": [ { "port": 80 } ], "externalIPs": ["203.0.113.10"] }`, nil,
+			[]string{"spec.externalIPs"}},
+		{"edge", `{ "type": "NodePort", "ports": [ { "port": 80, "nodePort": 32000 } ] }`, nil, nil},
+		{"edge", `{ "type": "LoadBalancer", "ports": [ { "port": 80 } ] }`, nil,
+			[]string{"spec.type"}},
+		{"default", `{ "type": "LoadBalancer", "ports": [ { "port": 80 } ] }`, map[string]string{"service.beta.kubernetes.io/load-balancer-source-ranges": "10.0.0.0/16, 0.0.0.0/0"},
+			[]string{"metadata.annotations[service.beta.kubernetes.io/load-balancer-source-ranges][1]"}},
+		{"default", `{ "type": "LoadBalancer", "ports": [ { "port": 80 } ], "loadBalancerSourceRanges": ["10.0.0.0/16"] }`, map[string]string{"service.beta.kubernetes.io/load-balancer-source-ranges": "0.0.0.0/0"}, nil},
+	}
+
+	for _, i := range tests {
+
+		s := &corev1.Service{}
+		if err := json.Unmarshal([]byte(i.spec), &s.Spec); err != nil {
+			t.Fatalf("error %v", err)
+		}
+		s.Namespace = i.namespace
+		s.Annotations = i.annotations
+
+		l := v.ValidateService(s, nil)
+
+		if len(l) != len(i.expected) {
+			t.Errorf("%s %s: violations were %v and expected are %v", i.namespace, i.spec, l, i.expected)
+			continue
+		}
+
+		for k, e := range i.expected {
+			if l[k].Field != e {
+				t.Errorf("%s %s: violation was %v and expected field is %s", i.namespace, i.spec, l[k], e)
+			}
+		}
+
+	}
+
+}
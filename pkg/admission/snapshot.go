@@ -0,0 +1,50 @@
+package admission
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Snapshot is an immutable validator loaded from a config file along with
+// the hash of the file content and the time it was loaded.
+type Snapshot struct {
+	Validator *NetworkAdmissionValidator
+	Hash      string
+	LoadedAt  time.Time
+}
+
+// LoadSnapshot reads and parses config file c into a new Snapshot.
+func LoadSnapshot(c string) (*Snapshot, error) {
+
+	b, err := readConfigFile(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSnapshot(b, c)
+}
+
+// NewSnapshot parses config content b, read from config file c, into a new
+// Snapshot.
+func NewSnapshot(b []byte, c string) (*Snapshot, error) {
+
+	validator, err := parseAdmissionValidator(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse config file %s: %v", c, err)
+	}
+
+	return &Snapshot{
+		Validator: validator,
+		Hash:      ConfigHash(b),
+		LoadedAt:  time.Now(),
+	}, nil
+}
+
+// ConfigHash returns the hex encoded sha256 of config content b.
+func ConfigHash(b []byte) string {
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
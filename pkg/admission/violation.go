@@ -0,0 +1,121 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Enforcement is the action taken when an object violates a rule
+type Enforcement string
+
+// All enforcement modes available
+const (
+	// EnforcementEnforce denies the request
+	EnforcementEnforce Enforcement = "enforce"
+	// EnforcementWarn allows the request and returns a warning to the client
+	EnforcementWarn Enforcement = "warn"
+	// EnforcementAudit allows the request and only records the violation
+	EnforcementAudit Enforcement = "audit"
+)
+
+// Violation describes a rule that a received object does not satisfy
+type Violation struct {
+	Field       string      `json:"field"`
+	Rule        RuleName    `json:"rule"`
+	Operator    Operator    `json:"operator,omitempty"`
+	Expected    string      `json:"expected,omitempty"`
+	Actual      string      `json:"actual,omitempty"`
+	Message     string      `json:"message"`
+	Enforcement Enforcement `json:"enforcement,omitempty"`
+}
+
+// Description returns the violation message along with the received value.
+func (v *Violation) Description() string {
+
+	if v.Actual == "" {
+		return v.Message
+	}
+
+	return fmt.Sprintf("%s, got %s", v.Message, v.Actual)
+}
+
+func (v *Violation) Error() string {
+
+	return fmt.Sprintf("%s: %s", v.Field, v.Description())
+}
+
+// ViolationList is the list of every violation found in an object
+type ViolationList []*Violation
+
+func (l ViolationList) Error() string {
+
+	msgs := make([]string, len(l))
+	for i, v := range l {
+		msgs[i] = v.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Filter returns the violations with enforcement mode e
+func (l ViolationList) Filter(e Enforcement) ViolationList {
+
+	var f ViolationList
+	for _, v := range l {
+		if v.Enforcement == e {
+			f = append(f, v)
+		}
+	}
+
+	return f
+}
+
+// withEnforcement sets enforcement mode e on the violations without one
+func (l ViolationList) withEnforcement(e Enforcement) ViolationList {
+
+	if e == "" {
+		e = EnforcementEnforce
+	}
+
+	for _, v := range l {
+		if v.Enforcement == "" {
+			v.Enforcement = e
+		}
+	}
+
+	return l
+}
+
+// aggregate combines the errors of every evaluated rule into a single result.
+func aggregate(errs []error) (bool, error) {
+
+	var l ViolationList
+
+	for _, err := range errs {
+
+		switch e := err.(type) {
+
+		case nil:
+
+		case ViolationList:
+
+			l = append(l, e...)
+
+		case *Violation:
+
+			l = append(l, e)
+
+		default:
+
+			l = append(l, &Violation{Message: e.Error()})
+
+		}
+	}
+
+	if len(l) == 0 {
+		return true, nil
+	}
+
+	return false, l
+
+}
@@ -0,0 +1,80 @@
+package admission
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PodSpec returns the pod spec of a Pod or the pod template spec of a
+// workload along with its field path. Deployments, StatefulSets,
+// DaemonSets, ReplicaSets, Jobs and CronJobs are supported.
+func PodSpec(obj runtime.Object) (*corev1.PodSpec, *field.Path, error) {
+
+	template := field.NewPath("spec", "template", "spec")
+	jobTemplate := field.NewPath("spec", "jobTemplate", "spec", "template", "spec")
+
+	switch o := obj.(type) {
+
+	case *corev1.Pod:
+
+		return &o.Spec, field.NewPath("spec"), nil
+
+	case *appsv1.Deployment:
+
+		return &o.Spec.Template.Spec, template, nil
+
+	case *appsv1.StatefulSet:
+
+		return &o.Spec.Template.Spec, template, nil
+
+	case *appsv1.DaemonSet:
+
+		return &o.Spec.Template.Spec, template, nil
+
+	case *appsv1.ReplicaSet:
+
+		return &o.Spec.Template.Spec, template, nil
+
+	case *batchv1.Job:
+
+		return &o.Spec.Template.Spec, template, nil
+
+	case *batchv1.CronJob:
+
+		return &o.Spec.JobTemplate.Spec.Template.Spec, jobTemplate, nil
+
+	case *batchv1beta1.CronJob:
+
+		return &o.Spec.JobTemplate.Spec.Template.Spec, jobTemplate, nil
+
+	}
+
+	return nil, nil, fmt.Errorf("%T does not have a pod template", obj)
+}
+
+// ValidateWorkload checks the container requests and limits of the pod
+// template of workload obj with the resources rules of the profile of
+// namespace.
+func (v *NetworkAdmissionValidator) ValidateWorkload(obj runtime.Object, namespace string, namespaceLabels map[string]string) (ViolationList, error) {
+
+	spec, fldPath, err := PodSpec(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := v.validators(namespace, namespaceLabels)
+
+	if ok, err := validators.ResourcesValidator.isValid(spec, fldPath); !ok {
+		l, _ := err.(ViolationList)
+		return l.withEnforcement(validators.Enforcement), nil
+	}
+
+	return nil, nil
+
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"crypto/tls"
 	"flag"
+	"time"
 
 	"github.com/golang/glog"
 )
@@ -13,6 +14,46 @@ type Config struct {
 	KeyFile       string
 	ConfigFile    string
 	ListenAddress string
+	// NamespaceLabelsFile holds the labels of every namespace, used to bind
+	// profiles to namespaces by label.
+	NamespaceLabelsFile string
+	// ReloadInterval is how often ConfigFile is checked for changes, zero
+	// disables reloading.
+	ReloadInterval time.Duration
+	// NamespaceController runs the controller creating the default
+	// NetworkPolicy of new namespaces along with the webhook.
+	NamespaceController bool
+	// NamespaceControllerInterval is how often the namespaces are listed by
+	// the namespace controller.
+	NamespaceControllerInterval time.Duration
+	// NamespaceControllerSince is set once when the namespace controller is
+	// installed, the namespaces created before it are only provisioned when
+	// they opt in. The zero time provisions every namespace.
+	NamespaceControllerSince time.Time
+}
+
+// timeValue is a flag.Value holding an RFC 3339 time
+type timeValue time.Time
+
+func (t *timeValue) String() string {
+
+	if t == nil || time.Time(*t).IsZero() {
+		return ""
+	}
+
+	return time.Time(*t).Format(time.RFC3339)
+}
+
+func (t *timeValue) Set(s string) error {
+
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return err
+	}
+
+	*t = timeValue(v)
+
+	return nil
 }
 
 // AddFlags parse flags
@@ -27,6 +68,16 @@ func (c *Config) AddFlags() {
 		"File containing validation rules --config-file.")
 	flag.StringVar(&c.ListenAddress, "listen-address", "0.0.0.0:443", ""+
 		"File containing validation rules --listen-address.")
+	flag.StringVar(&c.NamespaceLabelsFile, "namespace-labels-file", c.NamespaceLabelsFile, ""+
+		"File mapping namespace names to their labels, used to bind profiles to namespaces by label.")
+	flag.DurationVar(&c.ReloadInterval, "config-reload-interval", 10*time.Second, ""+
+		"How often --config-file is checked for changes, 0 disables reloading.")
+	flag.BoolVar(&c.NamespaceController, "namespace-controller", c.NamespaceController, ""+
+		"Create the default NetworkPolicy of the profile of new namespaces, uses the in cluster service account.")
+	flag.DurationVar(&c.NamespaceControllerInterval, "namespace-controller-interval", 30*time.Second, ""+
+		"How often the namespace controller lists the namespaces.")
+	flag.Var((*timeValue)(&c.NamespaceControllerSince), "namespace-controller-since", ""+
+		"RFC 3339 time the namespace controller was installed, older namespaces are only provisioned with the opt-in label.")
 
 }
 
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// serviceAccountDir holds the credentials mounted in every pod
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// restClient is a NamespaceClient calling the Kubernetes REST API
+type restClient struct {
+	host string
+	// tokenFile is read on every request since service account tokens are
+	// rotated
+	tokenFile string
+	client    *http.Client
+}
+
+// NewInClusterClient returns a NamespaceClient using the service account of
+// the pod the server runs in.
+func NewInClusterClient() (NamespaceClient, error) {
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("unable to load in cluster configuration, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be defined")
+	}
+
+	ca, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("unable to load in cluster configuration: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("unable to load in cluster configuration: no certificate in %s/ca.crt", serviceAccountDir)
+	}
+
+	return &restClient{
+		host:      "https://" + net.JoinHostPort(host, port),
+		tokenFile: serviceAccountDir + "/token",
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// statusError is returned for the responses without a 2xx status code
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status code %d: %s", e.code, e.msg)
+}
+
+// do sends a request with body, encoded as contentType, and decodes the
+// response in out when it is not nil
+func (c *restClient) do(method, path, contentType string, body interface{}, out interface{}) error {
+
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.host+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if c.tokenFile != "" {
+		token, err := ioutil.ReadFile(c.tokenFile)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{code: resp.StatusCode, msg: fmt.Sprintf("%s %s: %s", method, path, data)}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func (c *restClient) ListNamespaces() ([]corev1.Namespace, error) {
+
+	l := &corev1.NamespaceList{}
+	if err := c.do(http.MethodGet, "/api/v1/namespaces", "", nil, l); err != nil {
+		return nil, err
+	}
+
+	return l.Items, nil
+}
+
+func (c *restClient) CreateNetworkPolicy(p *networkingv1.NetworkPolicy) error {
+
+	p.APIVersion = "networking.k8s.io/v1"
+	p.Kind = "NetworkPolicy"
+
+	err := c.do(http.MethodPost, "/apis/networking.k8s.io/v1/namespaces/"+p.Namespace+"/networkpolicies", "application/json", p, nil)
+	if e, ok := err.(*statusError); ok && e.code == http.StatusConflict {
+		return nil
+	}
+
+	return err
+}
+
+func (c *restClient) AnnotateNamespace(name, key, value string) error {
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: value},
+		},
+	}
+
+	return c.do(http.MethodPatch, "/api/v1/namespaces/"+name, "application/merge-patch+json", patch, nil)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"time"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// NamespaceClient is the part of the Kubernetes API used by the namespace
+// controller
+type NamespaceClient interface {
+	// ListNamespaces returns every namespace of the cluster
+	ListNamespaces() ([]corev1.Namespace, error)
+	// CreateNetworkPolicy creates p in its namespace, an existing policy with
+	// the same name is left unchanged
+	CreateNetworkPolicy(p *networkingv1.NetworkPolicy) error
+	// AnnotateNamespace sets annotation key to value on namespace name
+	AnnotateNamespace(name, key, value string) error
+}
+
+// systemNamespaces are never provisioned by the namespace controller
+var systemNamespaces = map[string]bool{
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+// RunNamespaceController creates the default NetworkPolicy of the profile of
+// every namespace created after Config.NamespaceControllerSince, the
+// namespaces are listed every Config.NamespaceControllerInterval until stop
+// is closed.
+func (s *Server) RunNamespaceController(c NamespaceClient, stop <-chan struct{}) {
+
+	since := s.Config.NamespaceControllerSince
+
+	if err := s.syncNamespaces(c, since); err != nil {
+		glog.Error(err)
+	}
+
+	if s.Config.NamespaceControllerInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Config.NamespaceControllerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := s.syncNamespaces(c, since); err != nil {
+				glog.Error(err)
+			}
+		}
+	}
+}
+
+// syncNamespaces creates the default NetworkPolicy of the namespaces not
+// provisioned yet and annotates them so the policy is created only once.
+// Namespaces created before since are skipped unless they have the
+// DefaultNetworkPolicyLabel, every namespace is provisioned when since is
+// zero. System and terminating namespaces are always skipped.
+func (s *Server) syncNamespaces(c NamespaceClient, since time.Time) error {
+
+	l, err := c.ListNamespaces()
+	if err != nil {
+		return err
+	}
+
+	validator := s.currentSnapshot().Validator
+	var errs []error
+
+	for i := range l {
+
+		ns := &l[i]
+		if ns.Status.Phase == corev1.NamespaceTerminating || systemNamespaces[ns.Name] {
+			continue
+		}
+
+		if ns.CreationTimestamp.Time.Before(since) && ns.Labels[admission.DefaultNetworkPolicyLabel] != "true" {
+			continue
+		}
+
+		p := validator.DefaultNetworkPolicy(ns)
+		if p == nil {
+			continue
+		}
+
+		if err := c.CreateNetworkPolicy(p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := c.AnnotateNamespace(ns.Name, admission.DefaultNetworkPolicyAnnotation, p.Name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		glog.Infof("created default NetworkPolicy %s/%s", p.Namespace, p.Name)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
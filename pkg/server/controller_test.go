@@ -0,0 +1,338 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const namespaceValidator = `
+namespaceValidator:
+  warnOnCreate: true
+  defaultNetworkPolicy:
+    metadata:
+      name: default-deny
+    spec:
+      podSelector: {}
+      policyTypes:
+      - Ingress
+      - Egress
+profiles:
+- name: system
+  namespaces:
+  - kube-system
+`
+
+// fakeClient is an in memory NamespaceClient
+type fakeClient struct {
+	namespaces map[string]*corev1.Namespace
+	policies   map[string]*networkingv1.NetworkPolicy
+	// fail makes the requests on the namespace fail
+	fail string
+}
+
+func newFakeClient(namespaces ...corev1.Namespace) *fakeClient {
+
+	c := &fakeClient{namespaces: map[string]*corev1.Namespace{}, policies: map[string]*networkingv1.NetworkPolicy{}}
+	for i := range namespaces {
+		c.namespaces[namespaces[i].Name] = &namespaces[i]
+	}
+
+	return c
+}
+
+func (c *fakeClient) ListNamespaces() ([]corev1.Namespace, error) {
+
+	var l []corev1.Namespace
+	for _, ns := range c.namespaces {
+		l = append(l, *ns.DeepCopy())
+	}
+
+	return l, nil
+}
+
+func (c *fakeClient) CreateNetworkPolicy(p *networkingv1.NetworkPolicy) error {
+
+	if p.Namespace == c.fail {
+		return fmt.Errorf("unable to create NetworkPolicy %s/%s", p.Namespace, p.Name)
+	}
+
+	key := p.Namespace + "/" + p.Name
+	if _, ok := c.policies[key]; !ok {
+		c.policies[key] = p.DeepCopy()
+	}
+
+	return nil
+}
+
+func (c *fakeClient) AnnotateNamespace(name, key, value string) error {
+
+	ns, ok := c.namespaces[name]
+	if !ok {
+		return fmt.Errorf("namespace %s not found", name)
+	}
+
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[key] = value
+
+	return nil
+}
+
+func TestSyncNamespaces(t *testing.T) {
+
+	s := newTestServer(t, namespaceValidator)
+
+	since := time.Now()
+	created := metav1.NewTime(since.Add(time.Minute))
+
+	c := newFakeClient(
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", CreationTimestamp: created}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", CreationTimestamp: created, Annotations: map[string]string{admission.DefaultNetworkPolicyAnnotation: "custom"}}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", CreationTimestamp: created}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: created}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "broken", CreationTimestamp: created}},
+	)
+	c.fail = "broken"
+
+	if err := s.syncNamespaces(c, since); err == nil {
+		t.Errorf("expected an error for namespace broken")
+	}
+
+	if len(c.policies) != 1 || c.policies["team-a/default-deny"] == nil {
+		t.Fatalf("policies were %v and expected is team-a/default-deny", c.policies)
+	}
+
+	if p := c.policies["team-a/default-deny"]; len(p.Spec.PolicyTypes) != 2 {
+		t.Errorf("policy types were %v and expected are Ingress and Egress", p.Spec.PolicyTypes)
+	}
+
+	if a := c.namespaces["team-a"].Annotations[admission.DefaultNetworkPolicyAnnotation]; a != "default-deny" {
+		t.Errorf("annotation was %q and expected is default-deny", a)
+	}
+
+	if _, ok := c.namespaces["broken"].Annotations[admission.DefaultNetworkPolicyAnnotation]; ok {
+		t.Errorf("namespace broken was annotated without its policy")
+	}
+
+	// provisioned namespaces are skipped, even when their policy was deleted
+	delete(c.policies, "team-a/default-deny")
+	c.fail = ""
+	c.namespaces["team-c"] = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c", CreationTimestamp: created}}
+
+	if err := s.syncNamespaces(c, since); err != nil {
+		t.Errorf("error %v", err)
+	}
+
+	if len(c.policies) != 2 || c.policies["team-c/default-deny"] == nil || c.policies["broken/default-deny"] == nil {
+		t.Errorf("policies were %v and expected are team-c/default-deny and broken/default-deny", c.policies)
+	}
+
+}
+
+func TestSyncExistingNamespaces(t *testing.T) {
+
+	s := newTestServer(t, namespaceValidator)
+
+	since := time.Now()
+	existing := metav1.NewTime(since.Add(-time.Hour))
+	optIn := map[string]string{admission.DefaultNetworkPolicyLabel: "true"}
+
+	c := newFakeClient(
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy", CreationTimestamp: existing}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy-opt-in", CreationTimestamp: existing, Labels: optIn}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-public", CreationTimestamp: existing, Labels: optIn}},
+		corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-node-lease", CreationTimestamp: metav1.NewTime(since.Add(time.Minute))}},
+	)
+
+	if err := s.syncNamespaces(c, since); err != nil {
+		t.Errorf("error %v", err)
+	}
+
+	if len(c.policies) != 1 || c.policies["legacy-opt-in/default-deny"] == nil {
+		t.Errorf("policies were %v and expected is legacy-opt-in/default-deny", c.policies)
+	}
+
+	for _, name := range []string{"legacy", "kube-public", "kube-node-lease"} {
+		if a, ok := c.namespaces[name].Annotations[admission.DefaultNetworkPolicyAnnotation]; ok {
+			t.Errorf("namespace %s was annotated with %q and expected is left alone", name, a)
+		}
+	}
+
+}
+
+func TestRunNamespaceControllerRestart(t *testing.T) {
+
+	installed := time.Now().Add(-2 * time.Hour)
+
+	tests := []struct {
+		since    time.Time
+		expected []string
+	}{
+		{time.Time{}, []string{"legacy/default-deny", "team-a/default-deny", "team-b/default-deny"}},
+		{installed, []string{"team-a/default-deny", "team-b/default-deny"}},
+	}
+
+	for _, i := range tests {
+
+		c := newFakeClient(
+			corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy", CreationTimestamp: metav1.NewTime(installed.Add(-time.Hour))}},
+			corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", CreationTimestamp: metav1.NewTime(installed.Add(time.Minute))}},
+		)
+
+		s := newTestServer(t, namespaceValidator)
+		s.Config.NamespaceControllerSince = i.since
+		s.RunNamespaceController(c, nil)
+
+		// team-b is created while the controller is down, the restarted
+		// controller provisions it with the same cutoff
+		c.namespaces["team-b"] = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Minute))}}
+
+		s = newTestServer(t, namespaceValidator)
+		s.Config.NamespaceControllerSince = i.since
+		s.RunNamespaceController(c, nil)
+
+		if len(c.policies) != len(i.expected) {
+			t.Errorf("since %v: policies were %v and expected are %v", i.since, c.policies, i.expected)
+			continue
+		}
+
+		for _, k := range i.expected {
+			if c.policies[k] == nil {
+				t.Errorf("since %v: policies were %v and expected are %v", i.since, c.policies, i.expected)
+			}
+		}
+
+	}
+
+}
+
+func TestRESTClient(t *testing.T) {
+
+	var requests []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, r.Header.Get("Content-Type")))
+
+		switch {
+
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces":
+
+			json.NewEncoder(w).Encode(corev1.NamespaceList{Items: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}}})
+
+		case r.Method == http.MethodPost && r.URL.Path == "/apis/networking.k8s.io/v1/namespaces/team-a/networkpolicies":
+
+			p := &networkingv1.NetworkPolicy{}
+			if err := json.Unmarshal(body, p); err != nil || p.Kind != "NetworkPolicy" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+
+		case r.Method == http.MethodPost:
+
+			w.WriteHeader(http.StatusConflict)
+
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/namespaces/team-a":
+
+			w.Write(body)
+
+		default:
+
+			w.WriteHeader(http.StatusForbidden)
+
+		}
+	}))
+	defer ts.Close()
+
+	c := &restClient{host: ts.URL, client: ts.Client()}
+
+	l, err := c.ListNamespaces()
+	if err != nil || len(l) != 1 || l[0].Name != "team-a" {
+		t.Errorf("namespaces were %v and expected is team-a, error %v", l, err)
+	}
+
+	if err := c.CreateNetworkPolicy(&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "default-deny", Namespace: "team-a"}}); err != nil {
+		t.Errorf("error %v", err)
+	}
+
+	if err := c.CreateNetworkPolicy(&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "default-deny", Namespace: "team-b"}}); err != nil {
+		t.Errorf("an existing policy returned error %v", err)
+	}
+
+	if err := c.AnnotateNamespace("team-a", admission.DefaultNetworkPolicyAnnotation, "default-deny"); err != nil {
+		t.Errorf("error %v", err)
+	}
+
+	if err := c.AnnotateNamespace("team-b", admission.DefaultNetworkPolicyAnnotation, "default-deny"); err == nil {
+		t.Errorf("expected an error for a forbidden request")
+	}
+
+	if len(requests) != 5 || requests[3] != "PATCH /api/v1/namespaces/team-a application/merge-patch+json" {
+		t.Errorf("requests were %v", requests)
+	}
+
+}
+
+func TestAdmitNamespaces(t *testing.T) {
+
+	tests := []struct {
+		operation  admissionv1.Operation
+		namespace  string
+		controller bool
+		warning    string
+	}{
+		{admissionv1.Create, "team-a", true, "default NetworkPolicy default-deny will be created"},
+		{admissionv1.Create, "team-a", false, "has no NetworkPolicy"},
+		{admissionv1.Update, "team-a", true, ""},
+		{admissionv1.Create, "kube-system", true, ""},
+	}
+
+	s := newTestServer(t, namespaceValidator)
+	defer func() { namespacesValidator.controller = false }()
+
+	for _, i := range tests {
+
+		namespacesValidator.controller = i.controller
+
+		raw, err := json.Marshal(&corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: i.namespace},
+		})
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+
+		ar := admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			Name:      i.namespace,
+			Namespace: i.namespace,
+			Operation: i.operation,
+			Object:    runtime.RawExtension{Raw: raw},
+		}}
+
+		r := s.admitAny(ar)
+
+		if !r.Allowed || i.warning == "" && len(r.Warnings) > 0 || i.warning != "" && (len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], i.warning)) {
+			t.Errorf("%s %s: response was %v and expected warning is %q", i.operation, i.namespace, r, i.warning)
+		}
+
+	}
+
+}
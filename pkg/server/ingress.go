@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func init() {
+	registry.MustRegister(ingresses{})
+}
+
+// ingresses validates Ingresses with the ingress rules. Every served version
+// is converted to networking.k8s.io/v1.
+type ingresses struct{}
+
+func (ingresses) Path() string {
+	return "ingresses"
+}
+
+func (ingresses) Resources() []metav1.GroupVersionResource {
+	return []metav1.GroupVersionResource{
+		{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+		{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"},
+		{Group: "extensions", Version: "v1beta1", Resource: "ingresses"},
+	}
+}
+
+// Decode decodes Ingresses by the version of the raw object, the v1beta1
+// versions are converted to networking.k8s.io/v1
+func (ingresses) Decode(raw []byte) (runtime.Object, error) {
+
+	deserializer := codecs.UniversalDeserializer()
+	obj, _, err := deserializer.Decode(raw, nil, &networkingv1.Ingress{})
+	if err != nil {
+		return nil, err
+	}
+
+	switch p := obj.(type) {
+	case *networkingv1.Ingress:
+		return p, nil
+	case *networkingv1beta1.Ingress:
+		return ingressV1(p), nil
+	case *extensionsv1beta1.Ingress:
+		// both v1beta1 versions share the same fields
+		ingress := &networkingv1beta1.Ingress{}
+		if err := convertJSON(p, ingress); err != nil {
+			return nil, err
+		}
+		return ingressV1(ingress), nil
+	}
+
+	return nil, fmt.Errorf("%T is not an Ingress", obj)
+}
+
+// ingressV1 converts a networking.k8s.io/v1beta1 Ingress to v1, the
+// serviceName and servicePort of backends become their service.
+func ingressV1(in *networkingv1beta1.Ingress) *networkingv1.Ingress {
+
+	out := &networkingv1.Ingress{
+		ObjectMeta: in.ObjectMeta,
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			DefaultBackend:   ingressBackendV1(in.Spec.Backend),
+		},
+	}
+
+	for _, i := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, networkingv1.IngressTLS{Hosts: i.Hosts, SecretName: i.SecretName})
+	}
+
+	for _, i := range in.Spec.Rules {
+
+		rule := networkingv1.IngressRule{Host: i.Host}
+
+		if i.HTTP != nil {
+			rule.HTTP = &networkingv1.HTTPIngressRuleValue{}
+			for _, h := range i.HTTP.Paths {
+				rule.HTTP.Paths = append(rule.HTTP.Paths, networkingv1.HTTPIngressPath{
+					Path:     h.Path,
+					PathType: (*networkingv1.PathType)(h.PathType),
+					Backend:  *ingressBackendV1(&h.Backend),
+				})
+			}
+		}
+
+		out.Spec.Rules = append(out.Spec.Rules, rule)
+	}
+
+	return out
+}
+
+// ingressBackendV1 converts a v1beta1 backend to v1, nil stays nil
+func ingressBackendV1(in *networkingv1beta1.IngressBackend) *networkingv1.IngressBackend {
+
+	if in == nil {
+		return nil
+	}
+
+	out := &networkingv1.IngressBackend{Resource: in.Resource}
+
+	if in.ServiceName != "" {
+		out.Service = &networkingv1.IngressServiceBackend{Name: in.ServiceName}
+		if in.ServicePort.Type == intstr.String {
+			out.Service.Port.Name = in.ServicePort.StrVal
+		} else {
+			out.Service.Port.Number = in.ServicePort.IntVal
+		}
+	}
+
+	return out
+}
+
+func (ingresses) Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	return v.ValidateIngress(obj.(*networkingv1.Ingress), namespaceLabels)
+}
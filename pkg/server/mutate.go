@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	admissionv1 "k8s.io/api/admission/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// serveMutateNetworkPolicies handles the NetworkPolicy mutating webhook
+func (s *Server) serveMutateNetworkPolicies(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, s.mutateNetworkPolicies)
+}
+
+// mutateNetworkPolicies injects the defaults of the networkPolicyMutator
+// bound to the policy namespace, the request is always allowed and the
+// changes are returned as a JSONPatch.
+func (s *Server) mutateNetworkPolicies(ar admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
+
+	v := networkPolicies{}
+
+	handled := false
+	for _, gvr := range v.Resources() {
+		if ar.Request.Resource == gvr {
+			handled = true
+		}
+	}
+
+	if !handled {
+		err := fmt.Errorf("expect resource to be one of %v", v.Resources())
+		glog.Error(err)
+		return s.toAdmissionResponse(err)
+	}
+
+	if len(ar.Request.Object.Raw) == 0 {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	obj, err := v.Decode(ar.Request.Object.Raw)
+	if err != nil {
+		glog.Error(err)
+		return s.toAdmissionResponse(err)
+	}
+
+	networkPolicy := obj.(*networkingv1.NetworkPolicy)
+	if networkPolicy.Namespace == "" {
+		networkPolicy.Namespace = ar.Request.Namespace
+	}
+
+	validator := s.currentSnapshot().Validator
+	ops := validator.Mutate(networkPolicy, s.currentNamespaceLabels()[networkPolicy.Namespace])
+
+	reviewResponse := &admissionv1.AdmissionResponse{Allowed: true}
+	if len(ops) == 0 {
+		return reviewResponse
+	}
+
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		glog.Error(err)
+		return s.toAdmissionResponse(err)
+	}
+
+	glog.Infof("mutating NetworkPolicy %s/%s: %s", networkPolicy.Namespace, ar.Request.Name, patch)
+
+	patchType := admissionv1.PatchTypeJSONPatch
+	reviewResponse.Patch = patch
+	reviewResponse.PatchType = &patchType
+
+	return reviewResponse
+}
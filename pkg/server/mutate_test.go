@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	admissionv1 "k8s.io/api/admission/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const mutatingValidator = `
+networkPolicyMutator:
+  defaultPolicyTypes:
+  - Ingress
+  podSelectorLabels:
+    team: "a"
+  exceptCIDRs:
+  - "169.254.169.254/32"
+networkPolicyValidator:
+  allowedPolicyTypes:
+  - Ingress
+  podSelector:
+    matchLabels:
+      rules:
+      - name: "LabelValues"
+        operator: "In"
+        key: "team"
+        values: ["a"]
+  ingress:
+    from:
+      ipBlock:
+        except:
+          rules:
+          - name: "ListSize"
+            operator: "Ge"
+            value: 1
+`
+
+// mutateTemplate is an AdmissionReview creating the NetworkPolicy with spec
+// %s, its apiVersion is %s.
+const mutateTemplate = `{
+  "apiVersion": "%s",
+  "kind": "AdmissionReview",
+  "request": {
+    "uid": "705ab4f5-6393-11e8-b7cc-42010a800002",
+    "kind": {"group": "networking.k8s.io", "version": "v1", "kind": "NetworkPolicy"},
+    "resource": {"group": "networking.k8s.io", "version": "v1", "resource": "networkpolicies"},
+    "name": "test-network-policy",
+    "namespace": "default",
+    "operation": "CREATE",
+    "userInfo": {"username": "admin"},
+    "object": {
+      "apiVersion": "networking.k8s.io/v1",
+      "kind": "NetworkPolicy",
+      "metadata": {"name": "test-network-policy"},
+      "spec": %s
+    }
+  }
+}`
+
+// applyPatch applies the add operations of patch to JSON document doc
+func applyPatch(doc []byte, patch []byte) ([]byte, error) {
+
+	var obj interface{}
+	if err := json.Unmarshal(doc, &obj); err != nil {
+		return nil, err
+	}
+
+	var ops []admission.PatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+
+		if op.Op != "add" {
+			return nil, fmt.Errorf("unsupported operation %s", op.Op)
+		}
+
+		tokens := strings.Split(op.Path, "/")[1:]
+		for i, t := range tokens {
+			tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+		}
+
+		o, err := addValue(obj, tokens, op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op.Path, err)
+		}
+		obj = o
+	}
+
+	return json.Marshal(obj)
+}
+
+// addValue adds value at the JSON pointer tokens of node and returns node
+func addValue(node interface{}, tokens []string, value interface{}) (interface{}, error) {
+
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	switch n := node.(type) {
+
+	case map[string]interface{}:
+
+		if len(tokens) > 1 && n[tokens[0]] == nil {
+			return nil, fmt.Errorf("%s is missing", tokens[0])
+		}
+
+		child, err := addValue(n[tokens[0]], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = child
+		return n, nil
+
+	case []interface{}:
+
+		if tokens[0] == "-" && len(tokens) == 1 {
+			return append(n, value), nil
+		}
+
+		i, err := strconv.Atoi(tokens[0])
+		if err != nil || i >= len(n) || len(tokens) == 1 {
+			return nil, fmt.Errorf("unsupported index %s", tokens[0])
+		}
+
+		child, err := addValue(n[i], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+
+	}
+
+	return nil, fmt.Errorf("%s is not an object or a list", tokens[0])
+}
+
+func TestMutateNetworkPolicies(t *testing.T) {
+
+	tests := []struct {
+		apiVersion string
+		spec       string
+		patched    bool
+	}{
+		{"admission.k8s.io/v1", `{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "0.0.0.0/0"}}]}]}`, true},
+		{"admission.k8s.io/v1beta1", `{"podSelector": {}, "ingress": [{"from": [{"ipBlock": {"cidr": "0.0.0.0/0"}}]}]}`, true},
+		{"admission.k8s.io/v1", `{"podSelector": {"matchLabels": {"team": "a"}}, "policyTypes": ["Ingress"], "ingress": [{"from": [{"ipBlock": {"cidr": "10.0.0.0/8", "except": ["10.0.0.0/24"]}}]}]}`, false},
+		{"admission.k8s.io/v1", `{"podSelector": {"matchLabels": {"app": "web"}}, "ingress": [{"from": [{"ipBlock": {"cidr": "169.254.0.0/16", "except": ["169.254.1.0/24"]}}]}]}`, true},
+	}
+
+	s := newTestServer(t, mutatingValidator)
+	validator := s.currentSnapshot().Validator
+
+	for _, i := range tests {
+
+		body := fmt.Sprintf(mutateTemplate, i.apiVersion, i.spec)
+		r := httptest.NewRequest(http.MethodPost, "/mutate/networkpolicies", bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		s.serveMutateNetworkPolicies(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status code was %d and expected is %d", i.apiVersion, i.spec, w.Code, http.StatusOK)
+		}
+
+		var review struct {
+			metav1.TypeMeta
+			Response *admissionv1.AdmissionResponse `json:"response"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+			t.Fatalf("%s %s: error %v", i.apiVersion, i.spec, err)
+		}
+
+		if review.Response == nil || !review.Response.Allowed {
+			t.Fatalf("%s %s: response was %v and expected is allowed", i.apiVersion, i.spec, review.Response)
+		}
+
+		if (len(review.Response.Patch) > 0) != i.patched {
+			t.Errorf("%s %s: patch was %s and expected patched is %v", i.apiVersion, i.spec, review.Response.Patch, i.patched)
+		}
+
+		if !i.patched {
+			continue
+		}
+
+		if review.Response.PatchType == nil || *review.Response.PatchType != admissionv1.PatchTypeJSONPatch {
+			t.Errorf("%s %s: patch type was %v and expected is %s", i.apiVersion, i.spec, review.Response.PatchType, admissionv1.PatchTypeJSONPatch)
+		}
+
+		var ar struct {
+			Request struct {
+				Object json.RawMessage `json:"object"`
+			} `json:"request"`
+		}
+		if err := json.Unmarshal([]byte(body), &ar); err != nil {
+			t.Fatalf("%s %s: error %v", i.apiVersion, i.spec, err)
+		}
+
+		patched, err := applyPatch(ar.Request.Object, review.Response.Patch)
+		if err != nil {
+			t.Fatalf("%s %s: patch %s: error %v", i.apiVersion, i.spec, review.Response.Patch, err)
+		}
+
+		p := &networkingv1.NetworkPolicy{}
+		if err := json.Unmarshal(patched, p); err != nil {
+			t.Fatalf("%s %s: error %v", i.apiVersion, i.spec, err)
+		}
+		p.Namespace = "default"
+
+		if l := validator.Validate(p, nil); len(l) > 0 {
+			t.Errorf("%s %s: violations of the mutated policy %s were %v", i.apiVersion, i.spec, patched, l)
+		}
+
+	}
+
+}
+
+func TestMutateNetworkPoliciesResource(t *testing.T) {
+
+	s := newTestServer(t, mutatingValidator)
+
+	ar := admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+		Resource: metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+	}}
+
+	if r := s.mutateNetworkPolicies(ar); r.Allowed || r.Result == nil {
+		t.Errorf("response was %v and expected is an error", r)
+	}
+
+}
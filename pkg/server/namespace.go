@@ -0,0 +1,55 @@
+package server
+
+import (
+	"github.com/4ltieres/karepol/pkg/admission"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	registry.MustRegister(namespacesValidator)
+}
+
+// namespacesValidator is registered by pointer so NewServer can set whether
+// the namespace controller runs
+var namespacesValidator = &namespaces{}
+
+// namespaces returns the warnings of the namespaces being created
+type namespaces struct {
+	// controller is true when the namespace controller creates the default
+	// NetworkPolicy of new namespaces
+	controller bool
+}
+
+func (namespaces) Path() string {
+	return "namespaces"
+}
+
+func (namespaces) Resources() []metav1.GroupVersionResource {
+	return []metav1.GroupVersionResource{
+		{Group: "", Version: "v1", Resource: "namespaces"},
+	}
+}
+
+// Operations limits the namespace rules to new namespaces
+func (namespaces) Operations() []admissionv1.Operation {
+	return []admissionv1.Operation{admissionv1.Create}
+}
+
+func (namespaces) Decode(raw []byte) (runtime.Object, error) {
+
+	namespace := &corev1.Namespace{}
+	deserializer := codecs.UniversalDeserializer()
+	if _, _, err := deserializer.Decode(raw, nil, namespace); err != nil {
+		return nil, err
+	}
+
+	return namespace, nil
+}
+
+func (n namespaces) Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	return v.ValidateNamespace(obj.(*corev1.Namespace), n.controller)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	registry.MustRegister(networkPolicies{})
+}
+
+// networkPolicies validates NetworkPolicies with the network policy rules
+type networkPolicies struct{}
+
+func (networkPolicies) Path() string {
+	return "networkpolicies"
+}
+
+func (networkPolicies) Resources() []metav1.GroupVersionResource {
+	return []metav1.GroupVersionResource{
+		{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+		{Group: "extensions", Version: "v1beta1", Resource: "networkpolicies"},
+	}
+}
+
+// Decode decodes NetworkPolicies by the version of the raw object,
+// extensions/v1beta1 policies are converted to networking.k8s.io/v1
+func (networkPolicies) Decode(raw []byte) (runtime.Object, error) {
+
+	deserializer := codecs.UniversalDeserializer()
+	obj, _, err := deserializer.Decode(raw, nil, &networkingv1.NetworkPolicy{})
+	if err != nil {
+		return nil, err
+	}
+
+	switch p := obj.(type) {
+	case *networkingv1.NetworkPolicy:
+		return p, nil
+	case *extensionsv1beta1.NetworkPolicy:
+		networkPolicy := &networkingv1.NetworkPolicy{}
+		return networkPolicy, convertJSON(p, networkPolicy)
+	}
+
+	return nil, fmt.Errorf("%T is not a NetworkPolicy", obj)
+}
+
+func (networkPolicies) Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	return v.Validate(obj.(*networkingv1.NetworkPolicy), namespaceLabels)
+}
+
+func (networkPolicies) ValidateUpdate(v *admission.NetworkAdmissionValidator, old, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	return v.ValidateUpdate(old.(*networkingv1.NetworkPolicy), obj.(*networkingv1.NetworkPolicy), namespaceLabels)
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"github.com/4ltieres/karepol/pkg/admission"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	registry.MustRegister(pods{})
+}
+
+// pods validates Pods with the pod security rules
+type pods struct{}
+
+func (pods) Path() string {
+	return "pods"
+}
+
+func (pods) Resources() []metav1.GroupVersionResource {
+	return []metav1.GroupVersionResource{
+		{Group: "", Version: "v1", Resource: "pods"},
+	}
+}
+
+func (pods) Decode(raw []byte) (runtime.Object, error) {
+
+	pod := &corev1.Pod{}
+	deserializer := codecs.UniversalDeserializer()
+	if _, _, err := deserializer.Decode(raw, nil, pod); err != nil {
+		return nil, err
+	}
+
+	return pod, nil
+}
+
+func (pods) Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	return v.ValidatePod(obj.(*corev1.Pod), namespaceLabels)
+}
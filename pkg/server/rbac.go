@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	"github.com/golang/glog"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	registry.MustRegister(rbac{})
+}
+
+// rbac validates Roles, ClusterRoles and their bindings with the RBAC rules
+type rbac struct{}
+
+func (rbac) Path() string {
+	return "rbac"
+}
+
+func (rbac) Resources() []metav1.GroupVersionResource {
+	return []metav1.GroupVersionResource{
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+	}
+}
+
+// Decode decodes RBAC objects by the kind of the raw object
+func (rbac) Decode(raw []byte) (runtime.Object, error) {
+
+	deserializer := codecs.UniversalDeserializer()
+	obj, _, err := deserializer.Decode(raw, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch obj.(type) {
+	case *rbacv1.Role, *rbacv1.ClusterRole, *rbacv1.RoleBinding, *rbacv1.ClusterRoleBinding:
+		return obj, nil
+	}
+
+	return nil, fmt.Errorf("%T is not an RBAC object", obj)
+}
+
+func (rbac) Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	namespace := ""
+	if o, ok := obj.(metav1.Object); ok {
+		namespace = o.GetNamespace()
+	}
+
+	violations, err := v.ValidateRBAC(obj, namespace, namespaceLabels)
+	if err != nil {
+		glog.Error(err)
+	}
+
+	return violations
+}
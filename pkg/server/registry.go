@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ResourceValidator validates the objects of a resource type. Every
+// registered validator is served on its own path and by the /validate
+// endpoint.
+type ResourceValidator interface {
+	// Path is the path the validator is served on, without the leading slash
+	Path() string
+	// Resources returns the group version resources handled by the validator
+	Resources() []metav1.GroupVersionResource
+	// Decode decodes the raw object of an admission request
+	Decode(raw []byte) (runtime.Object, error)
+	// Validate returns the violations of obj against the rules of v,
+	// namespaceLabels are the labels of the object namespace
+	Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList
+}
+
+// operationValidator is implemented by the validators that only apply to
+// some operations, the other requests are only checked by the field rules.
+type operationValidator interface {
+	Operations() []admissionv1.Operation
+}
+
+// handlesOperation returns true when validator v applies to operation o
+func handlesOperation(v ResourceValidator, o admissionv1.Operation) bool {
+
+	ov, ok := v.(operationValidator)
+	if !ok {
+		return true
+	}
+
+	for _, i := range ov.Operations() {
+		if i == o {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateValidator is implemented by the validators comparing an updated
+// object with its previous version
+type updateValidator interface {
+	// ValidateUpdate returns the violations of the changes from old to obj
+	// against the rules of v
+	ValidateUpdate(v *admission.NetworkAdmissionValidator, old, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList
+}
+
+// Registry holds the resource validators by the resources they handle
+type Registry struct {
+	validators []ResourceValidator
+	resources  map[metav1.GroupVersionResource]ResourceValidator
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+
+	return &Registry{resources: map[metav1.GroupVersionResource]ResourceValidator{}}
+}
+
+// registry holds the validators served by the server, each validator
+// registers itself on init.
+var registry = NewRegistry()
+
+// Register adds v to the registry, it fails when v handles a resource or
+// path already registered.
+func (r *Registry) Register(v ResourceValidator) error {
+
+	for _, i := range r.validators {
+		if i.Path() == v.Path() {
+			return fmt.Errorf("path /%s is already registered", v.Path())
+		}
+	}
+
+	for _, gvr := range v.Resources() {
+		if _, ok := r.resources[gvr]; ok {
+			return fmt.Errorf("resource %s is already registered", gvr)
+		}
+	}
+
+	for _, gvr := range v.Resources() {
+		r.resources[gvr] = v
+	}
+	r.validators = append(r.validators, v)
+
+	return nil
+}
+
+// MustRegister is like Register but panics when v can't be registered
+func (r *Registry) MustRegister(v ResourceValidator) {
+
+	if err := r.Register(v); err != nil {
+		panic(err)
+	}
+}
+
+// Validators returns the registered validators in registration order
+func (r *Registry) Validators() []ResourceValidator {
+
+	return r.validators
+}
+
+// Lookup returns the validator handling resource gvr
+func (r *Registry) Lookup(gvr metav1.GroupVersionResource) (ResourceValidator, bool) {
+
+	v, ok := r.resources[gvr]
+	return v, ok
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// pathValidator is a networkPolicies validator served on another path
+type pathValidator struct {
+	networkPolicies
+	path string
+}
+
+func (v pathValidator) Path() string {
+	return v.path
+}
+
+func TestRegistry(t *testing.T) {
+
+	r := NewRegistry()
+
+	if err := r.Register(networkPolicies{}); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	if err := r.Register(networkPolicies{}); err == nil {
+		t.Errorf("registering a path twice was expected to fail")
+	}
+
+	if err := r.Register(pathValidator{path: "other"}); err == nil {
+		t.Errorf("registering a resource twice was expected to fail")
+	}
+
+	for _, gvr := range (networkPolicies{}).Resources() {
+		if v, ok := r.Lookup(gvr); !ok || v.Path() != "networkpolicies" {
+			t.Errorf("%s: validator was %v and expected is networkpolicies", gvr, v)
+		}
+	}
+
+	if v, ok := r.Lookup(metav1.GroupVersionResource{Version: "v1", Resource: "pods"}); ok {
+		t.Errorf("pods: validator was %v and expected is none", v)
+	}
+
+	if l := r.Validators(); len(l) != 1 {
+		t.Errorf("validators were %v and expected is 1", l)
+	}
+
+}
+
+func TestAdmitAny(t *testing.T) {
+
+	networkPolicy := `{"apiVersion": "networking.k8s.io/v1", "kind": "NetworkPolicy", "metadata": {"name": "test"}, "spec": {"podSelector": {}, "policyTypes": ["Egress"]}}`
+	ingress := `{"apiVersion": "extensions/v1beta1", "kind": "Ingress", "metadata": {"name": "test"}, "spec": {"rules": [{"host": "shop.example.org", "http": {"paths": [{"path": "/", "backend": {"serviceName": "web", "servicePort": 80}}]}}]}}`
+
+	deployment := `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "test"}, "spec": {"template": {"spec": {"containers": [{"name": "app"}]}}}}`
+	cronJob := `{"apiVersion": "batch/v1beta1", "kind": "CronJob", "metadata": {"name": "test"}, "spec": {"schedule": "* * * * *", "jobTemplate": {"spec": {"template": {"spec": {"containers": [{"name": "app"}]}}}}}}`
+
+	binding := `{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRoleBinding", "metadata": {"name": "test"}, "roleRef": {"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "cluster-admin"}, "subjects": [{"kind": "User", "name": "developer"}]}`
+
+	tests := []struct {
+		resource metav1.GroupVersionResource
+		object   string
+		allowed  bool
+		message  string
+	}{
+		{metav1.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, networkPolicy, false, "spec.policyTypes[0]"},
+		{metav1.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "networkpolicies"}, networkPolicy, false, "spec.policyTypes[0]"},
+		{metav1.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}, ingress, false, "spec.rules[0].host"},
+		{metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, deployment, false, "spec.template.spec.containers[0].resources.requests[cpu]"},
+		{metav1.GroupVersionResource{Group: "batch", Version: "v1beta1", Resource: "cronjobs"}, cronJob, false, "spec.jobTemplate.spec.template.spec.containers[0].resources.requests[cpu]"},
+		{metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "test"}}`, false, "does not have a pod template"},
+		{metav1.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, binding, false, "subjects[0]"},
+		{metav1.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, deployment, false, "is not an RBAC object"},
+		{metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}, `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "test"}, "data": {"mode": "debug"}}`, false, "data[mode]"},
+		{metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}, `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "test"}, "data": {"mode": "release"}}`, true, ""},
+	}
+
+	s := newTestServer(t, ingressOnly+"ingressValidator:\n  host:\n    rules:\n    - name: DomainSuffix\n      operator: In\n      values: [example.com]\n"+
+		"resourcesValidator:\n  cpu:\n    rules:\n    - name: ResourceRequest\n      operator: Exists\n"+
+		"rbacValidator:\n  bindings:\n    rules:\n    - name: Subjects\n      operator: In\n      key: ClusterRole:cluster-admin\n      values: ['Group:system:masters']\n"+
+		"fieldValidators:\n- apiGroups: ['']\n  resources: [configmaps]\n  path: data[*]\n  rules:\n  - name: FieldValue\n    operator: NotIn\n    values: [debug]\n")
+
+	for _, i := range tests {
+
+		ar := admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+			Resource:  i.resource,
+			Namespace: "default",
+			Object:    runtime.RawExtension{Raw: []byte(i.object)},
+		}}
+
+		r := s.admitAny(ar)
+
+		if r.Allowed != i.allowed {
+			t.Errorf("%s: allowed was %v and expected is %v", i.resource, r.Allowed, i.allowed)
+		}
+
+		if i.allowed {
+			continue
+		}
+
+		if r.Result == nil || !strings.Contains(r.Result.Message, i.message) {
+			t.Errorf("%s: status was %v and expected message contains %q", i.resource, r.Result, i.message)
+		}
+
+	}
+
+}
+
+func TestDecodeNetworkPolicy(t *testing.T) {
+
+	policy := `{"apiVersion": "%s", "kind": "NetworkPolicy", "metadata": {"name": "test"}, "spec": {"podSelector": {}, "policyTypes": ["Egress"], "egress": [{"to": [{"ipBlock": {"cidr": "10.0.0.0/8"}}]}]}}`
+
+	for _, apiVersion := range []string{"networking.k8s.io/v1", "extensions/v1beta1"} {
+
+		obj, err := (networkPolicies{}).Decode([]byte(fmt.Sprintf(policy, apiVersion)))
+		if err != nil {
+			t.Fatalf("%s: error %v", apiVersion, err)
+		}
+
+		p, ok := obj.(*networkingv1.NetworkPolicy)
+		if !ok {
+			t.Fatalf("%s: object was %T and expected is a networking.k8s.io/v1 NetworkPolicy", apiVersion, obj)
+		}
+
+		if len(p.Spec.Egress) != 1 || len(p.Spec.Egress[0].To) != 1 || p.Spec.Egress[0].To[0].IPBlock == nil || p.Spec.Egress[0].To[0].IPBlock.CIDR != "10.0.0.0/8" {
+			t.Errorf("%s: spec was %+v", apiVersion, p.Spec)
+		}
+
+	}
+
+	if _, err := (networkPolicies{}).Decode([]byte(`{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "test"}}`)); err == nil {
+		t.Errorf("decoding a Service was expected to fail")
+	}
+
+}
+
+func TestDecodeIngress(t *testing.T) {
+
+	v1beta1 := `{"apiVersion": "%s", "kind": "Ingress", "metadata": {"name": "test"}, "spec": {"backend": {"serviceName": "default", "servicePort": "http"}, "tls": [{"hosts": ["shop.example.com"]}], "rules": [{"host": "shop.example.com", "http": {"paths": [{"path": "/", "pathType": "Prefix", "backend": {"serviceName": "web", "servicePort": 80}}]}}]}}`
+	v1 := `{"apiVersion": "networking.k8s.io/v1", "kind": "Ingress", "metadata": {"name": "test"}, "spec": {"defaultBackend": {"service": {"name": "default", "port": {"name": "http"}}}, "tls": [{"hosts": ["shop.example.com"]}], "rules": [{"host": "shop.example.com", "http": {"paths": [{"path": "/", "pathType": "Prefix", "backend": {"service": {"name": "web", "port": {"number": 80}}}}]}}]}}`
+
+	tests := []string{fmt.Sprintf(v1beta1, "extensions/v1beta1"), fmt.Sprintf(v1beta1, "networking.k8s.io/v1beta1"), v1}
+
+	for _, i := range tests {
+
+		obj, err := (ingresses{}).Decode([]byte(i))
+		if err != nil {
+			t.Fatalf("%s: error %v", i, err)
+		}
+
+		p, ok := obj.(*networkingv1.Ingress)
+		if !ok {
+			t.Fatalf("%s: object was %T and expected is a networking.k8s.io/v1 Ingress", i, obj)
+		}
+
+		if b := p.Spec.DefaultBackend; b == nil || b.Service == nil || b.Service.Name != "default" || b.Service.Port.Name != "http" {
+			t.Errorf("%s: default backend was %+v", i, b)
+		}
+
+		if len(p.Spec.TLS) != 1 || len(p.Spec.Rules) != 1 || p.Spec.Rules[0].Host != "shop.example.com" || p.Spec.Rules[0].HTTP == nil || len(p.Spec.Rules[0].HTTP.Paths) != 1 {
+			t.Fatalf("%s: spec was %+v", i, p.Spec)
+		}
+
+		h := p.Spec.Rules[0].HTTP.Paths[0]
+		if h.Path != "/" || h.PathType == nil || *h.PathType != networkingv1.PathTypePrefix || h.Backend.Service == nil || h.Backend.Service.Name != "web" || h.Backend.Service.Port.Number != 80 {
+			t.Errorf("%s: path was %+v", i, h)
+		}
+
+	}
+
+}
+
+func TestSchemeVersions(t *testing.T) {
+
+	for _, v := range registry.Validators() {
+		for _, gvr := range v.Resources() {
+			if gv := (schema.GroupVersion{Group: gvr.Group, Version: gvr.Version}); !scheme.IsVersionRegistered(gv) {
+				t.Errorf("%s: %s is not registered in the scheme", v.Path(), gv)
+			}
+		}
+	}
+
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	"github.com/golang/glog"
+)
+
+// currentSnapshot returns the active validation rules
+func (s *Server) currentSnapshot() *admission.Snapshot {
+
+	return s.snapshot.Load().(*admission.Snapshot)
+}
+
+// WatchConfig polls the config file every Config.ReloadInterval until stop is
+// closed and swaps the active validation rules when its content changes.
+// The file content is compared rather than its modification time so that
+// ConfigMap volumes, which replace the file through a symlink swap, are
+// reloaded as well.
+func (s *Server) WatchConfig(stop <-chan struct{}) {
+
+	if s.Config.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.reloadConfig()
+			s.reloadNamespaceLabels()
+		}
+	}
+}
+
+// reloadConfig loads the config file when its content differs from the active
+// snapshot. A config that can't be loaded is logged once and the previous
+// snapshot is kept.
+func (s *Server) reloadConfig() bool {
+
+	b, err := ioutil.ReadFile(s.Config.ConfigFile)
+	if err != nil {
+		glog.Errorf("unable to reload config file, keeping config %s: %v", s.currentSnapshot().Hash, err)
+		return false
+	}
+
+	hash := admission.ConfigHash(b)
+	if hash == s.currentSnapshot().Hash || hash == s.rejectedHash {
+		return false
+	}
+
+	snapshot, err := admission.NewSnapshot(b, s.Config.ConfigFile)
+	if err != nil {
+		s.rejectedHash = hash
+		glog.Errorf("rejected config %s, keeping config %s: %v", hash, s.currentSnapshot().Hash, err)
+		return false
+	}
+
+	s.rejectedHash = ""
+	s.snapshot.Store(snapshot)
+	glog.Infof("loaded config %s from %s", snapshot.Hash, s.Config.ConfigFile)
+
+	return true
+}
+
+// currentNamespaceLabels returns the active namespace labels snapshot
+func (s *Server) currentNamespaceLabels() admission.NamespaceLabels {
+
+	return s.namespaceLabels.Load().(admission.NamespaceLabels)
+}
+
+// loadNamespaceLabels loads the namespace labels file, an empty snapshot is
+// used when no file is configured.
+func (s *Server) loadNamespaceLabels() error {
+
+	if s.Config.NamespaceLabelsFile == "" {
+		s.namespaceLabels.Store(admission.NamespaceLabels{})
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(s.Config.NamespaceLabelsFile)
+	if err != nil {
+		return err
+	}
+
+	l, err := admission.ParseNamespaceLabels(b, s.Config.NamespaceLabelsFile)
+	if err != nil {
+		return err
+	}
+
+	s.namespaceLabelsHash = admission.ConfigHash(b)
+	s.namespaceLabels.Store(l)
+
+	return nil
+}
+
+// reloadNamespaceLabels loads the namespace labels file when its content
+// changed, the previous snapshot is kept when it can't be loaded.
+func (s *Server) reloadNamespaceLabels() bool {
+
+	if s.Config.NamespaceLabelsFile == "" {
+		return false
+	}
+
+	b, err := ioutil.ReadFile(s.Config.NamespaceLabelsFile)
+	if err != nil {
+		glog.Errorf("unable to reload namespace labels file, keeping previous labels: %v", err)
+		return false
+	}
+
+	hash := admission.ConfigHash(b)
+	if hash == s.namespaceLabelsHash {
+		return false
+	}
+	s.namespaceLabelsHash = hash
+
+	l, err := admission.ParseNamespaceLabels(b, s.Config.NamespaceLabelsFile)
+	if err != nil {
+		glog.Errorf("rejected namespace labels %s, keeping previous labels: %v", hash, err)
+		return false
+	}
+
+	s.namespaceLabels.Store(l)
+	glog.Infof("loaded namespace labels %s from %s", hash, s.Config.NamespaceLabelsFile)
+
+	return true
+}
+
+// serveConfigz reports the hash and load time of the active config
+func (s *Server) serveConfigz(w http.ResponseWriter, r *http.Request) {
+
+	snapshot := s.currentSnapshot()
+
+	respBytes, err := json.Marshal(struct {
+		Hash     string    `json:"hash"`
+		LoadedAt time.Time `json:"loadedAt"`
+	}{snapshot.Hash, snapshot.LoadedAt})
+	if err != nil {
+		glog.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(respBytes); err != nil {
+		glog.Error(err)
+	}
+}
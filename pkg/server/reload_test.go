@@ -0,0 +1,107 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	"github.com/4ltieres/karepol/pkg/config"
+)
+
+const (
+	ingressOnly = "networkPolicyValidator:\n  allowedPolicyTypes:\n  - Ingress\n"
+	egressOnly  = "networkPolicyValidator:\n  allowedPolicyTypes:\n  - Egress\n"
+	invalid     = "networkPolicyValidator: ["
+)
+
+// newReloadServer returns a server whose config file is a symlink to a
+// versioned file, the same layout kubelet uses for ConfigMap volumes.
+func newReloadServer(t *testing.T, dir, content string) *Server {
+
+	writeVersion(t, dir, "v0", content)
+
+	c := filepath.Join(dir, "validator.yaml")
+	if err := os.Symlink(filepath.Join(dir, "..data", "validator.yaml"), c); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	snapshot, err := admission.LoadSnapshot(c)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	s := &Server{Config: config.Config{ConfigFile: c}}
+	s.snapshot.Store(snapshot)
+
+	return s
+}
+
+// writeVersion writes content in a new version directory and atomically
+// points the ..data symlink to it.
+func writeVersion(t *testing.T, dir, version, content string) {
+
+	if err := os.Mkdir(filepath.Join(dir, version), 0755); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, version, "validator.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(version, tmp); err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("error %v", err)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "karepol")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newReloadServer(t, dir, ingressOnly)
+	initial := s.currentSnapshot()
+
+	if s.reloadConfig() {
+		t.Errorf("config was reloaded without changes")
+	}
+
+	writeVersion(t, dir, "v1", invalid)
+
+	if s.reloadConfig() {
+		t.Errorf("invalid config was loaded")
+	}
+
+	if s.currentSnapshot() != initial {
+		t.Errorf("previous config was not kept after an invalid config")
+	}
+
+	writeVersion(t, dir, "v2", egressOnly)
+
+	if !s.reloadConfig() {
+		t.Fatalf("changed config was not reloaded")
+	}
+
+	current := s.currentSnapshot()
+	if current.Hash != admission.ConfigHash([]byte(egressOnly)) {
+		t.Errorf("hash was %s and expected is the hash of the new config", current.Hash)
+	}
+
+	if current.LoadedAt.Before(initial.LoadedAt) {
+		t.Errorf("load time was %v and expected is after %v", current.LoadedAt, initial.LoadedAt)
+	}
+
+	if p := current.Validator.NetworkPolicyValidator.PolicyTypes; len(p) != 1 || p[0] != admission.PolicyTypeEgress {
+		t.Errorf("allowed policy types were %v and expected is [Egress]", p)
+	}
+
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/api/admission/v1beta1"
+)
+
+// The admit functions handle admission.k8s.io/v1 reviews, requests received
+// as admission.k8s.io/v1beta1 are converted to v1 and their responses
+// converted back. Both versions have the same fields.
+
+func toV1AdmissionRequest(r *v1beta1.AdmissionRequest) *admissionv1.AdmissionRequest {
+
+	if r == nil {
+		return nil
+	}
+
+	return &admissionv1.AdmissionRequest{
+		UID:                r.UID,
+		Kind:               r.Kind,
+		Resource:           r.Resource,
+		SubResource:        r.SubResource,
+		RequestKind:        r.RequestKind,
+		RequestResource:    r.RequestResource,
+		RequestSubResource: r.RequestSubResource,
+		Name:               r.Name,
+		Namespace:          r.Namespace,
+		Operation:          admissionv1.Operation(r.Operation),
+		UserInfo:           r.UserInfo,
+		Object:             r.Object,
+		OldObject:          r.OldObject,
+		DryRun:             r.DryRun,
+		Options:            r.Options,
+	}
+}
+
+func toV1beta1AdmissionResponse(r *admissionv1.AdmissionResponse) *v1beta1.AdmissionResponse {
+
+	if r == nil {
+		return nil
+	}
+
+	response := &v1beta1.AdmissionResponse{
+		UID:              r.UID,
+		Allowed:          r.Allowed,
+		Result:           r.Result,
+		Patch:            r.Patch,
+		AuditAnnotations: r.AuditAnnotations,
+		Warnings:         r.Warnings,
+	}
+
+	if r.PatchType != nil {
+		pt := v1beta1.PatchType(*r.PatchType)
+		response.PatchType = &pt
+	}
+
+	return response
+}
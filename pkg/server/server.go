@@ -3,7 +3,9 @@ package server
 import (
 	"flag"
 	"net/http"
+	"sync/atomic"
 
+	"github.com/4ltieres/karepol/pkg/admission"
 	"github.com/4ltieres/karepol/pkg/config"
 )
 
@@ -11,10 +13,29 @@ import (
 type Server struct {
 	HTTPServer *http.Server
 	Config     config.Config
+	// snapshot holds the *admission.Snapshot loaded from Config.ConfigFile,
+	// it is shared read-only by every admission request and replaced as a
+	// whole when the config file changes.
+	snapshot atomic.Value
+	// rejectedHash is the hash of the last config that failed to load.
+	rejectedHash string
+	// namespaceLabels holds the admission.NamespaceLabels loaded from
+	// Config.NamespaceLabelsFile and is replaced as a whole like snapshot.
+	namespaceLabels atomic.Value
+	// namespaceLabelsHash is the hash of the last namespace labels file read.
+	namespaceLabelsHash string
 }
 
-func (s *Server) serveNetworkPolicies(w http.ResponseWriter, r *http.Request) {
-	s.serve(w, r, s.admitNetworkPolicies)
+// serveResource returns the handler of the path of validator v
+func (s *Server) serveResource(v ResourceValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.serve(w, r, s.admitResource(v))
+	}
+}
+
+// serveValidate handles every registered resource
+func (s *Server) serveValidate(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, s.admitAny)
 }
 
 //IsTLSEnable Check if tls configs was passed
@@ -28,19 +49,35 @@ func (s *Server) IsTLSEnable() bool {
 	return false
 }
 
-// NewServer return a server
-func NewServer() *Server {
+// NewServer return a server, it fails when the validation rules can't be loaded
+func NewServer() (*Server, error) {
 
 	c := config.Config{}
 	c.AddFlags()
 	flag.Parse()
 
+	snapshot, err := admission.LoadSnapshot(c.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Server{
 		HTTPServer: &http.Server{
 			Addr: c.ListenAddress,
 		},
 		Config: c}
+	s.snapshot.Store(snapshot)
+	namespacesValidator.controller = c.NamespaceController
+
+	if err := s.loadNamespaceLabels(); err != nil {
+		return nil, err
+	}
 
-	http.HandleFunc("/networkpolicies", s.serveNetworkPolicies)
-	return s
+	for _, v := range registry.Validators() {
+		http.HandleFunc("/"+v.Path(), s.serveResource(v))
+	}
+	http.HandleFunc("/validate", s.serveValidate)
+	http.HandleFunc("/mutate/networkpolicies", s.serveMutateNetworkPolicies)
+	http.HandleFunc("/configz", s.serveConfigz)
+	return s, nil
 }
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"github.com/4ltieres/karepol/pkg/admission"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	registry.MustRegister(services{})
+}
+
+// services validates Services with the service rules
+type services struct{}
+
+func (services) Path() string {
+	return "services"
+}
+
+func (services) Resources() []metav1.GroupVersionResource {
+	return []metav1.GroupVersionResource{
+		{Group: "", Version: "v1", Resource: "services"},
+	}
+}
+
+func (services) Decode(raw []byte) (runtime.Object, error) {
+
+	service := &corev1.Service{}
+	deserializer := codecs.UniversalDeserializer()
+	if _, _, err := deserializer.Decode(raw, nil, service); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+func (services) Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	return v.ValidateService(obj.(*corev1.Service), namespaceLabels)
+}
@@ -5,70 +5,177 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"runtime/debug"
 	"strings"
 
 	"github.com/4ltieres/karepol/pkg/admission"
 	"github.com/golang/glog"
+	admissionv1 "k8s.io/api/admission/v1"
 	"k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
-	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
-// only allow networkpolicies with some requirements.
-func (s *Server) admitNetworkPolicies(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
+// admitResource returns the admitFunc of the validator served on its own
+// path, it only admits the resources handled by v.
+func (s *Server) admitResource(v ResourceValidator) admitFunc {
+
+	return func(ar admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
+
+		for _, gvr := range v.Resources() {
+			if ar.Request.Resource == gvr {
+				return s.validate(v, ar)
+			}
+		}
+
+		err := fmt.Errorf("expect resource to be one of %v", v.Resources())
+		glog.Error(err)
+		return s.toAdmissionResponse(err)
+	}
+}
+
+// admitAny dispatches the request to the validator registered for the
+// requested resource, the resources without a validator are only checked
+// against the field rules.
+func (s *Server) admitAny(ar admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
 
-	glog.V(2).Info("admitting networkpolicies")
-	var group, version, resource string
+	v, _ := registry.Lookup(ar.Request.Resource)
 
-	switch ar.Request.Resource {
-	case metav1.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}:
+	return s.validate(v, ar)
+}
 
-		group = "networking.k8s.io"
-		version = "v1"
-		resource = "networkpolicies"
+// validate decodes the requested object and validates it with v, when it is
+// not nil and handles the request operation, and with the field rules
+// against the current rules. Updates are also compared with the old object
+// when v is an updateValidator. Requests without an object, e.g.
+// deletions, are allowed.
+func (s *Server) validate(v ResourceValidator, ar admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
 
-	case metav1.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "networkpolicies"}:
-		group = "extensions"
-		version = "v1beta1"
-		resource = "networkpolicies"
+	glog.V(2).Infof("admitting %s", ar.Request.Resource.Resource)
 
+	// delete and connect requests have no object to validate
+	if len(ar.Request.Object.Raw) == 0 {
+		return &admissionv1.AdmissionResponse{Allowed: true}
 	}
 
-	networkPolicyResource := metav1.GroupVersionResource{Group: group, Version: version, Resource: resource}
+	validator := s.currentSnapshot().Validator
 
-	if ar.Request.Resource != networkPolicyResource {
-		err := fmt.Errorf("expect resource to be %s ", networkPolicyResource)
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(ar.Request.Object.Raw); err != nil {
 		glog.Error(err)
 		return s.toAdmissionResponse(err)
 	}
 
-	raw := ar.Request.Object.Raw
-	networkPolicy := networkingv1.NetworkPolicy{}
-	deserializer := codecs.UniversalDeserializer()
-	if _, _, err := deserializer.Decode(raw, nil, &networkPolicy); err != nil {
-		glog.Error(err)
-		return s.toAdmissionResponse(err)
+	if u.GetNamespace() == "" {
+		u.SetNamespace(ar.Request.Namespace)
+	}
+
+	namespaceLabels := s.currentNamespaceLabels()[u.GetNamespace()]
+	var violations admission.ViolationList
+
+	if v != nil && handlesOperation(v, ar.Request.Operation) {
+
+		obj, err := v.Decode(ar.Request.Object.Raw)
+		if err != nil {
+			glog.Error(err)
+			return s.toAdmissionResponse(err)
+		}
+
+		if o, ok := obj.(metav1.Object); ok && o.GetNamespace() == "" {
+			o.SetNamespace(u.GetNamespace())
+		}
+
+		violations = v.Validate(validator, obj, namespaceLabels)
+
+		if uv, ok := v.(updateValidator); ok && ar.Request.Operation == admissionv1.Update && len(ar.Request.OldObject.Raw) > 0 {
+
+			old, err := v.Decode(ar.Request.OldObject.Raw)
+			if err != nil {
+				glog.Error(err)
+				return s.toAdmissionResponse(err)
+			}
+
+			violations = append(violations, uv.ValidateUpdate(validator, old, obj, namespaceLabels)...)
+		}
+	}
+
+	violations = append(violations, validator.ValidateObject(u, ar.Request.Resource, namespaceLabels)...)
+
+	gk := metav1.GroupKind{Group: ar.Request.Kind.Group, Kind: ar.Request.Kind.Kind}
+	return s.toValidationResponse(ar, gk, violations)
+}
+
+// toValidationResponse builds the AdmissionResponse of an object validated
+// with violations. The request is denied by the violations in enforce mode,
+// violations in warn mode are returned as warnings and violations in audit
+// mode are added to the audit annotations. Every violation is logged.
+func (s *Server) toValidationResponse(ar admissionv1.AdmissionReview, gk metav1.GroupKind, violations admission.ViolationList) *admissionv1.AdmissionResponse {
+
+	reviewResponse := admissionv1.AdmissionResponse{}
+
+	for _, v := range violations {
+		glog.Infof("%s violation by %s on %s %s/%s: %s",
+			v.Enforcement, ar.Request.UserInfo.Username, gk.Kind, ar.Request.Namespace, ar.Request.Name, v.Error())
 	}
-	reviewResponse := v1beta1.AdmissionResponse{}
 
-	validator := admission.NewAdmissionValidator(s.Config.ConfigFile)
+	for _, v := range violations.Filter(admission.EnforcementWarn) {
+		reviewResponse.Warnings = append(reviewResponse.Warnings, v.Error())
+	}
 
-	ok, err := validator.IsValid(&networkPolicy)
+	if audited := violations.Filter(admission.EnforcementAudit); len(audited) > 0 {
+		b, err := json.Marshal(audited)
+		if err != nil {
+			glog.Error(err)
+		}
+		reviewResponse.AuditAnnotations = map[string]string{"violations": string(b)}
+	}
 
-	reviewResponse.Allowed = ok
+	enforced := violations.Filter(admission.EnforcementEnforce)
+	reviewResponse.Allowed = len(enforced) == 0
 
 	if !reviewResponse.Allowed {
-		reviewResponse.Result = &metav1.Status{Message: strings.TrimSpace(fmt.Sprintf(err.Error()))}
+		reviewResponse.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: strings.TrimSpace(enforced.Error()),
+			Details: &metav1.StatusDetails{
+				Name:   ar.Request.Name,
+				Group:  gk.Group,
+				Kind:   gk.Kind,
+				Causes: toStatusCauses(enforced),
+			},
+		}
 	}
 
 	return &reviewResponse
 }
 
+// toStatusCauses renders every violation of a denied object as a status cause
+func toStatusCauses(err error) []metav1.StatusCause {
+
+	violations, ok := err.(admission.ViolationList)
+	if !ok {
+		return []metav1.StatusCause{{Message: err.Error()}}
+	}
+
+	causes := make([]metav1.StatusCause, len(violations))
+	for i, v := range violations {
+		causes[i] = metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: v.Description(),
+			Field:   v.Field,
+		}
+	}
+
+	return causes
+}
+
 // toAdmissionResponse is a helper function to create an AdmissionResponse
 // with an embedded error
-func (s *Server) toAdmissionResponse(err error) *v1beta1.AdmissionResponse {
-	return &v1beta1.AdmissionResponse{
+func (s *Server) toAdmissionResponse(err error) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
 		Result: &metav1.Status{
 			Message: err.Error(),
 		},
@@ -76,11 +183,36 @@ func (s *Server) toAdmissionResponse(err error) *v1beta1.AdmissionResponse {
 }
 
 // admitFunc is the type we use for all of our validators and mutators
-type admitFunc func(v1beta1.AdmissionReview) *v1beta1.AdmissionResponse
+type admitFunc func(admissionv1.AdmissionReview) *admissionv1.AdmissionResponse
+
+// recoverAdmit turns a panic of admit into a denial, a bug in a validator
+// must neither let the object through nor kill the request.
+func recoverAdmit(admit admitFunc) admitFunc {
+	return func(ar admissionv1.AdmissionReview) (response *admissionv1.AdmissionResponse) {
+
+		defer func() {
+			if r := recover(); r != nil {
+				glog.Errorf("panic admitting %s %s/%s: %v\n%s", ar.Request.Kind.Kind, ar.Request.Namespace, ar.Request.Name, r, debug.Stack())
+				response = &admissionv1.AdmissionResponse{
+					Result: &metav1.Status{
+						Code:    http.StatusInternalServerError,
+						Message: fmt.Sprintf("internal error admitting %s %s, the request is denied", ar.Request.Kind.Kind, ar.Request.Name),
+					},
+				}
+			}
+		}()
+
+		return admit(ar)
+	}
+}
 
 // serve handles the http portion of a request prior to handing to an admit
-// function
+// function. AdmissionReviews are accepted in admission.k8s.io/v1 and
+// v1beta1, the response is sent in the version of the request. A panic of
+// admit denies the request.
 func (s *Server) serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
+	admit = recoverAdmit(admit)
+
 	var body []byte
 	if r.Body != nil {
 		if data, err := ioutil.ReadAll(r.Body); err == nil {
@@ -92,35 +224,78 @@ func (s *Server) serve(w http.ResponseWriter, r *http.Request, admit admitFunc)
 	contentType := r.Header.Get("Content-Type")
 	if contentType != "application/json" {
 		glog.Errorf("contentType=%s, expect application/json", contentType)
+		http.Error(w, "expect application/json content type", http.StatusUnsupportedMediaType)
 		return
 	}
 
 	glog.V(2).Info(fmt.Sprintf("handling request: %s", body))
 
-	// The AdmissionReview that was sent to the webhook
-	requestedAdmissionReview := v1beta1.AdmissionReview{}
+	deserializer := codecs.UniversalDeserializer()
+	obj, gvk, err := deserializer.Decode(body, nil, nil)
+	if err != nil {
+		glog.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// The AdmissionReview that will be returned
-	responseAdmissionReview := v1beta1.AdmissionReview{}
+	var responseObj runtime.Object
 
-	deserializer := codecs.UniversalDeserializer()
-	if _, _, err := deserializer.Decode(body, nil, &requestedAdmissionReview); err != nil {
+	switch *gvk {
+
+	case admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"):
+
+		requestedAdmissionReview, ok := obj.(*admissionv1.AdmissionReview)
+		if !ok || requestedAdmissionReview.Request == nil {
+			glog.Errorf("expected v1.AdmissionReview with a request but got %T", obj)
+			http.Error(w, "admission review request is missing", http.StatusBadRequest)
+			return
+		}
+
+		responseAdmissionReview := &admissionv1.AdmissionReview{}
+		responseAdmissionReview.SetGroupVersionKind(*gvk)
+		responseAdmissionReview.Response = admit(*requestedAdmissionReview)
+		// Return the same UID
+		responseAdmissionReview.Response.UID = requestedAdmissionReview.Request.UID
+		responseObj = responseAdmissionReview
+
+	case v1beta1.SchemeGroupVersion.WithKind("AdmissionReview"):
+
+		requestedAdmissionReview, ok := obj.(*v1beta1.AdmissionReview)
+		if !ok || requestedAdmissionReview.Request == nil {
+			glog.Errorf("expected v1beta1.AdmissionReview with a request but got %T", obj)
+			http.Error(w, "admission review request is missing", http.StatusBadRequest)
+			return
+		}
+
+		responseAdmissionReview := &v1beta1.AdmissionReview{}
+		responseAdmissionReview.SetGroupVersionKind(*gvk)
+		responseAdmissionReview.Response = toV1beta1AdmissionResponse(admit(admissionv1.AdmissionReview{
+			Request: toV1AdmissionRequest(requestedAdmissionReview.Request),
+		}))
+		// Return the same UID
+		responseAdmissionReview.Response.UID = requestedAdmissionReview.Request.UID
+		responseObj = responseAdmissionReview
+
+	default:
+
+		err := fmt.Errorf("unsupported group version kind: %v", gvk)
 		glog.Error(err)
-		responseAdmissionReview.Response = s.toAdmissionResponse(err)
-	} else {
-		// pass to admitFunc
-		responseAdmissionReview.Response = admit(requestedAdmissionReview)
-	}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 
-	// Return the same UID
-	responseAdmissionReview.Response.UID = requestedAdmissionReview.Request.UID
+	}
 
-	glog.V(2).Info(fmt.Sprintf("sending response: %v", responseAdmissionReview.Response))
+	glog.V(2).Info(fmt.Sprintf("sending response: %v", responseObj))
 
-	respBytes, err := json.Marshal(responseAdmissionReview)
+	respBytes, err := json.Marshal(responseObj)
 	if err != nil {
 		glog.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
 	if _, err := w.Write(respBytes); err != nil {
 		glog.Error(err)
 	}
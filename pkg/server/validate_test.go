@@ -0,0 +1,338 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/4ltieres/karepol/pkg/admission"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidationResponse(t *testing.T) {
+
+	enforced := &admission.Violation{Field: "spec.ingress[0].from[0].ipBlock.cidr", Rule: admission.MaskBitsSize, Message: "enforced", Enforcement: admission.EnforcementEnforce}
+	warned := &admission.Violation{Field: "spec.policyTypes[1]", Message: "warned", Enforcement: admission.EnforcementWarn}
+	audited := &admission.Violation{Field: "spec.ingress[0].ports[0].port", Rule: admission.PortNumber, Message: "audited", Enforcement: admission.EnforcementAudit}
+
+	tests := []struct {
+		violations admission.ViolationList
+		allowed    bool
+		warnings   int
+		audited    int
+		causes     int
+	}{
+		{nil, true, 0, 0, 0},
+		{admission.ViolationList{warned}, true, 1, 0, 0},
+		{admission.ViolationList{audited}, true, 0, 1, 0},
+		{admission.ViolationList{enforced, warned, audited}, false, 1, 1, 1},
+	}
+
+	s := &Server{}
+	ar := admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{Name: "test-network-policy", Namespace: "team-a"}}
+	gk := metav1.GroupKind{Group: "networking.k8s.io", Kind: "NetworkPolicy"}
+
+	for _, i := range tests {
+
+		r := s.toValidationResponse(ar, gk, i.violations)
+
+		if r.Allowed != i.allowed {
+			t.Errorf("%v: allowed was %v and expected is %v", i.violations, r.Allowed, i.allowed)
+		}
+
+		if len(r.Warnings) != i.warnings {
+			t.Errorf("%v: warnings were %v and expected are %d", i.violations, r.Warnings, i.warnings)
+		}
+
+		var a admission.ViolationList
+		if i.audited > 0 {
+			if err := json.Unmarshal([]byte(r.AuditAnnotations["violations"]), &a); err != nil {
+				t.Errorf("%v: error %v", i.violations, err)
+			}
+		}
+
+		if len(a) != i.audited {
+			t.Errorf("%v: audit annotations were %v and expected are %d violations", i.violations, r.AuditAnnotations, i.audited)
+		}
+
+		if i.allowed {
+			if r.Result != nil {
+				t.Errorf("%v: status was %v and expected is nil", i.violations, r.Result)
+			}
+			continue
+		}
+
+		if r.Result == nil || r.Result.Details == nil || len(r.Result.Details.Causes) != i.causes {
+			t.Fatalf("%v: status was %v and expected %d causes", i.violations, r.Result, i.causes)
+		}
+
+		if c := r.Result.Details.Causes[0]; c.Field != enforced.Field {
+			t.Errorf("%v: cause was %v and expected field is %s", i.violations, c, enforced.Field)
+		}
+
+	}
+
+}
+
+// reviewTemplate is an AdmissionReview creating the NetworkPolicy with policy
+// type %s, its apiVersion is %s.
+const reviewTemplate = `{
+  "apiVersion": "%s",
+  "kind": "AdmissionReview",
+  "request": {
+    "uid": "705ab4f5-6393-11e8-b7cc-42010a800002",
+    "kind": {"group": "networking.k8s.io", "version": "v1", "kind": "NetworkPolicy"},
+    "resource": {"group": "networking.k8s.io", "version": "v1", "resource": "networkpolicies"},
+    "name": "test-network-policy",
+    "namespace": "default",
+    "operation": "CREATE",
+    "userInfo": {"username": "admin"},
+    "object": {
+      "apiVersion": "networking.k8s.io/v1",
+      "kind": "NetworkPolicy",
+      "metadata": {"name": "test-network-policy"},
+      "spec": {"podSelector": {}, "policyTypes": ["%s"]}
+    }
+  }
+}`
+
+func newTestServer(t *testing.T, content string) *Server {
+
+	snapshot, err := admission.NewSnapshot([]byte(content), "validator.yaml")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+
+	s := &Server{}
+	s.snapshot.Store(snapshot)
+	s.namespaceLabels.Store(admission.NamespaceLabels{})
+
+	return s
+}
+
+func TestServe(t *testing.T) {
+
+	tests := []struct {
+		apiVersion string
+		policyType string
+		allowed    bool
+	}{
+		{"admission.k8s.io/v1", "Ingress", true},
+		{"admission.k8s.io/v1", "Egress", false},
+		{"admission.k8s.io/v1beta1", "Ingress", true},
+		{"admission.k8s.io/v1beta1", "Egress", false},
+	}
+
+	s := newTestServer(t, ingressOnly)
+
+	for _, i := range tests {
+
+		body := fmt.Sprintf(reviewTemplate, i.apiVersion, i.policyType)
+		r := httptest.NewRequest(http.MethodPost, "/networkpolicies", bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		s.serveResource(networkPolicies{})(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status code was %d and expected is %d", i.apiVersion, i.policyType, w.Code, http.StatusOK)
+		}
+
+		var review struct {
+			metav1.TypeMeta
+			Response *admissionv1.AdmissionResponse `json:"response"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+			t.Fatalf("%s %s: error %v", i.apiVersion, i.policyType, err)
+		}
+
+		if review.APIVersion != i.apiVersion || review.Kind != "AdmissionReview" {
+			t.Errorf("%s %s: response was %s %s and expected is %s AdmissionReview", i.apiVersion, i.policyType, review.APIVersion, review.Kind, i.apiVersion)
+		}
+
+		if review.Response == nil {
+			t.Fatalf("%s %s: response is missing", i.apiVersion, i.policyType)
+		}
+
+		if review.Response.UID != "705ab4f5-6393-11e8-b7cc-42010a800002" {
+			t.Errorf("%s %s: uid was %q", i.apiVersion, i.policyType, review.Response.UID)
+		}
+
+		if review.Response.Allowed != i.allowed {
+			t.Errorf("%s %s: allowed was %v and expected is %v", i.apiVersion, i.policyType, review.Response.Allowed, i.allowed)
+		}
+
+	}
+
+}
+
+func TestServeBadRequest(t *testing.T) {
+
+	tests := []struct {
+		contentType string
+		body        string
+		code        int
+	}{
+		{"text/plain", fmt.Sprintf(reviewTemplate, "admission.k8s.io/v1", "Ingress"), http.StatusUnsupportedMediaType},
+		{"application/json", "{", http.StatusBadRequest},
+		{"application/json", `{"apiVersion": "admission.k8s.io/v2", "kind": "AdmissionReview"}`, http.StatusBadRequest},
+		{"application/json", `{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"}`, http.StatusBadRequest},
+		{"application/json", `{"apiVersion": "v1", "kind": "Namespace"}`, http.StatusBadRequest},
+	}
+
+	s := newTestServer(t, ingressOnly)
+
+	for _, i := range tests {
+
+		r := httptest.NewRequest(http.MethodPost, "/networkpolicies", bytes.NewBufferString(i.body))
+		r.Header.Set("Content-Type", i.contentType)
+		w := httptest.NewRecorder()
+
+		s.serveResource(networkPolicies{})(w, r)
+
+		if w.Code != i.code {
+			t.Errorf("%s: status code was %d and expected is %d", i.body, w.Code, i.code)
+		}
+
+	}
+
+}
+
+func TestServeRecovers(t *testing.T) {
+
+	s := newTestServer(t, ingressOnly)
+
+	panicking := func(ar admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
+		var p *admissionv1.AdmissionResponse
+		p.Allowed = true
+		return p
+	}
+
+	for _, apiVersion := range []string{"admission.k8s.io/v1", "admission.k8s.io/v1beta1"} {
+
+		body := fmt.Sprintf(reviewTemplate, apiVersion, "Ingress")
+		r := httptest.NewRequest(http.MethodPost, "/networkpolicies", bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		s.serve(w, r, panicking)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status code was %d and expected is %d", apiVersion, w.Code, http.StatusOK)
+		}
+
+		var review struct {
+			Response *admissionv1.AdmissionResponse `json:"response"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil || review.Response == nil {
+			t.Fatalf("%s: response was %s, error %v", apiVersion, w.Body.String(), err)
+		}
+
+		if review.Response.Allowed || review.Response.UID != "705ab4f5-6393-11e8-b7cc-42010a800002" {
+			t.Errorf("%s: response was %v and expected is a denial", apiVersion, review.Response)
+		}
+
+		if review.Response.Result == nil || review.Response.Result.Code != http.StatusInternalServerError {
+			t.Errorf("%s: status was %v and expected code is %d", apiVersion, review.Response.Result, http.StatusInternalServerError)
+		}
+
+	}
+
+}
+
+func TestAdmitUpdate(t *testing.T) {
+
+	policy := `{"apiVersion": "networking.k8s.io/v1", "kind": "NetworkPolicy", "metadata": {"name": "test"}, "spec": {"podSelector": {"matchLabels": {"app": "%s"}}, "policyTypes": ["Ingress"]}}`
+
+	tests := []struct {
+		operation admissionv1.Operation
+		old       string
+		allowed   bool
+	}{
+		{admissionv1.Update, fmt.Sprintf(policy, "web"), true},
+		{admissionv1.Update, fmt.Sprintf(policy, "db"), false},
+		{admissionv1.Create, "", true},
+	}
+
+	s := newTestServer(t, ingressOnly+"  updates:\n    rules:\n    - name: PodSelectorChange\n      operator: In\n      values: ['false']\n")
+
+	for _, i := range tests {
+
+		ar := admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+			Resource:  metav1.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+			Namespace: "default",
+			Operation: i.operation,
+			Object:    runtime.RawExtension{Raw: []byte(fmt.Sprintf(policy, "web"))},
+			OldObject: runtime.RawExtension{Raw: []byte(i.old)},
+		}}
+
+		r := s.admitAny(ar)
+
+		if r.Allowed != i.allowed {
+			t.Errorf("%s %s: allowed was %v and expected is %v, status %v", i.operation, i.old, r.Allowed, i.allowed, r.Result)
+		}
+
+	}
+
+}
+
+func TestServeDelete(t *testing.T) {
+
+	// the old object would be denied by the Ingress only rules
+	body := `{
+  "apiVersion": "admission.k8s.io/v1",
+  "kind": "AdmissionReview",
+  "request": {
+    "uid": "705ab4f5-6393-11e8-b7cc-42010a800002",
+    "kind": {"group": "networking.k8s.io", "version": "v1", "kind": "NetworkPolicy"},
+    "resource": {"group": "networking.k8s.io", "version": "v1", "resource": "networkpolicies"},
+    "name": "test-network-policy",
+    "namespace": "default",
+    "operation": "DELETE",
+    "userInfo": {"username": "admin"},
+    "oldObject": {
+      "apiVersion": "networking.k8s.io/v1",
+      "kind": "NetworkPolicy",
+      "metadata": {"name": "test-network-policy"},
+      "spec": {"podSelector": {}, "policyTypes": ["Egress"]}
+    }
+  }
+}`
+
+	s := newTestServer(t, ingressOnly)
+
+	tests := []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/validate", s.serveValidate},
+		{"/networkpolicies", s.serveResource(networkPolicies{})},
+	}
+
+	for _, i := range tests {
+
+		r := httptest.NewRequest(http.MethodPost, i.path, bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		i.handler(w, r)
+
+		var review struct {
+			Response *admissionv1.AdmissionResponse `json:"response"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil || review.Response == nil {
+			t.Fatalf("%s: response was %s, error %v", i.path, w.Body.String(), err)
+		}
+
+		if !review.Response.Allowed || review.Response.UID != "705ab4f5-6393-11e8-b7cc-42010a800002" {
+			t.Errorf("%s: response was %v and expected is allowed", i.path, review.Response)
+		}
+
+	}
+
+}
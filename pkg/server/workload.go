@@ -0,0 +1,63 @@
+package server
+
+import (
+	"github.com/4ltieres/karepol/pkg/admission"
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	registry.MustRegister(workloads{})
+}
+
+// workloads validates the pod templates of workloads with the resources
+// rules
+type workloads struct{}
+
+func (workloads) Path() string {
+	return "workloads"
+}
+
+func (workloads) Resources() []metav1.GroupVersionResource {
+	return []metav1.GroupVersionResource{
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Group: "apps", Version: "v1", Resource: "statefulsets"},
+		{Group: "apps", Version: "v1", Resource: "daemonsets"},
+		{Group: "apps", Version: "v1", Resource: "replicasets"},
+		{Group: "batch", Version: "v1", Resource: "jobs"},
+		{Group: "batch", Version: "v1", Resource: "cronjobs"},
+		{Group: "batch", Version: "v1beta1", Resource: "cronjobs"},
+	}
+}
+
+// Decode decodes workloads by the kind of the raw object
+func (workloads) Decode(raw []byte) (runtime.Object, error) {
+
+	deserializer := codecs.UniversalDeserializer()
+	obj, _, err := deserializer.Decode(raw, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := admission.PodSpec(obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (workloads) Validate(v *admission.NetworkAdmissionValidator, obj runtime.Object, namespaceLabels map[string]string) admission.ViolationList {
+
+	namespace := ""
+	if o, ok := obj.(metav1.Object); ok {
+		namespace = o.GetNamespace()
+	}
+
+	violations, err := v.ValidateWorkload(obj, namespace, namespaceLabels)
+	if err != nil {
+		glog.Error(err)
+	}
+
+	return violations
+}